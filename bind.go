@@ -0,0 +1,138 @@
+package xhttpclient
+
+import (
+	"errors"
+	"fmt"
+	urlpkg "net/url"
+	"reflect"
+)
+
+// RequestEndpoint is implemented by the structs bound with NewRequest to describe the endpoint.
+type RequestEndpoint interface {
+	// Endpoint returns the method and the path template, e.g. "GET", "/users/{id}".
+	Endpoint() (method, pathTemplate string)
+}
+
+// NewRequest is a request described by the struct v, see Bind.
+// An empty method returned by Endpoint is reported by Do.
+//
+//	type GetRepo struct {
+//		Owner  string `path:"owner"`
+//		Repo   string `path:"repo"`
+//		Tenant string `header:"X-Tenant,omitempty"`
+//	}
+//
+//	func (GetRepo) Endpoint() (string, string) { return http.MethodGet, "/repos/{owner}/{repo}" }
+func NewRequest(v RequestEndpoint) *XRequestBuilder {
+	xr := new(XRequestBuilder).Bind(v)
+	if xr.method == "" && xr.err == nil {
+		xr.err = fmt.Errorf("bind %T: missing method", v)
+	}
+	return xr
+}
+
+// Bind populates the request from the exported fields of the struct v tagged with:
+//
+//   - `path:"name"`: the PathParam
+//   - `query:"name,opts"`: the query parameter, see QueryStruct for the options
+//   - `header:"Name,omitempty"`: the header, a slice adds a value per element
+//   - `body:""`: the Body, a nil value is skipped
+//
+// The untagged fields are ignored, the untagged embedded structs are flattened.
+// If v implements RequestEndpoint, the method and the PathTemplate are set too.
+// The values are read right away, an invalid v is reported by Do.
+func (xr *XRequestBuilder) Bind(v any) *XRequestBuilder {
+	if ep, ok := v.(RequestEndpoint); ok {
+		method, pathTemplate := ep.Endpoint()
+		if method != "" {
+			xr.method = method
+		}
+		if pathTemplate != "" {
+			xr.PathTemplate(pathTemplate)
+		}
+	}
+
+	if err := xr.bind(v); err != nil && xr.err == nil {
+		xr.err = fmt.Errorf("bind %T: %w", v, err)
+	}
+	return xr
+}
+
+func (xr *XRequestBuilder) bind(v any) error {
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	t := rv.Type()
+
+	for _, f := range cachedStructFields(t, "path") {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		values, err := formatValues(fv, f)
+		if err != nil {
+			return fmt.Errorf("path '%s': %w", f.name, err)
+		}
+		switch len(values) {
+		case 0:
+		case 1:
+			xr.PathParam(f.name, values[0])
+		default:
+			return fmt.Errorf("path '%s': multiple values", f.name)
+		}
+	}
+
+	query := make(urlpkg.Values)
+	for _, f := range cachedStructFields(t, "query") {
+		if err = addQueryField(query, rv, f); err != nil {
+			return err
+		}
+	}
+	for k, vv := range query {
+		for _, v := range vv {
+			xr.AddQuery(k, v)
+		}
+	}
+
+	for _, f := range cachedStructFields(t, "header") {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || f.hasOpt("omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		values, err := formatValues(fv, f)
+		if err != nil {
+			return fmt.Errorf("header '%s': %w", f.name, err)
+		}
+		for i, v := range values {
+			if i == 0 {
+				xr.SetHeader(f.name, v)
+			} else {
+				xr.AddHeader(f.name, v)
+			}
+		}
+	}
+
+	hasBody := false
+	for _, f := range cachedStructFields(t, "body") {
+		if hasBody {
+			return errors.New("multiple body fields")
+		}
+		hasBody = true
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || isNilValue(fv) {
+			continue
+		}
+		xr.Body(fv.Interface())
+	}
+
+	return nil
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package xhttpclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type testTenant struct {
+	Tenant string `header:"X-Tenant,omitempty"`
+}
+
+type testUpdateRepo struct {
+	testTenant
+	Owner  string   `path:"owner"`
+	Repo   string   `path:"repo"`
+	DryRun bool     `query:"dry_run,omitempty"`
+	Fields []string `query:"fields,comma"`
+	Tags   []string `header:"X-Tag"`
+	Patch  *struct {
+		Description string `json:"description"`
+	} `body:""`
+	Untagged string
+}
+
+func (testUpdateRepo) Endpoint() (string, string) {
+	return http.MethodPatch, "/repos/{owner}/{repo}"
+}
+
+func TestNewRequest(t *testing.T) {
+	var got struct {
+		Method string      `json:"method"`
+		URL    string      `json:"url"`
+		Header http.Header `json:"header"`
+		Body   string      `json:"body"`
+	}
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			json.NewEncoder(w).Encode(map[string]any{
+				"method": r.Method,
+				"url":    r.URL.RequestURI(),
+				"header": r.Header,
+				"body":   string(body),
+			})
+		}))
+
+	in := testUpdateRepo{
+		testTenant: testTenant{Tenant: "t1"},
+		Owner:      "electric bubble",
+		Repo:       "x/y",
+		Fields:     []string{"name", "size"},
+		Tags:       []string{"a", "b"},
+		Untagged:   "ignored",
+	}
+	in.Patch = &struct {
+		Description string `json:"description"`
+	}{Description: "desc"}
+
+	if _, _, err := cli.Do(&got, nil, NewRequest(in)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Method != http.MethodPatch || got.URL != "/repos/electric%20bubble/x%2Fy?fields=name%2Csize" {
+		t.Fatalf("request = %s %s", got.Method, got.URL)
+	}
+	if got.Header.Get("X-Tenant") != "t1" || strings.Join(got.Header.Values("X-Tag"), ",") != "a,b" {
+		t.Fatalf("header = %v", got.Header)
+	}
+	if got.Body != `{"description":"desc"}`+"\n" {
+		t.Fatalf("body = %q", got.Body)
+	}
+
+	// no body, omitempty
+	in.Patch, in.Tenant, in.DryRun = nil, "", true
+	got.Header = nil
+	if _, _, err := cli.Do(&got, nil, NewRequest(&in)); err != nil {
+		t.Fatal(err)
+	}
+	if got.Body != "" || got.Header.Get("X-Tenant") != "" || !strings.Contains(got.URL, "dry_run=true") {
+		t.Fatalf("got = %+v", got)
+	}
+
+	// Bind on a builder, missing path param
+	_, _, err := cli.Do(&got, nil, NewPost().Bind(struct {
+		ID string `path:"id"`
+	}{}).PathTemplate("/users/{id}"))
+	if err == nil || !strings.Contains(err.Error(), "empty param 'id'") {
+		t.Fatalf("err = %v", err)
+	}
+
+	_, _, err = cli.Do(&got, nil, NewPost().Path("users").Bind(struct {
+		A string `body:""`
+		B string `body:""`
+	}{}))
+	if err == nil || !strings.Contains(err.Error(), "multiple body fields") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+type testNoMethod struct {
+	ID string `path:"id"`
+}
+
+func (testNoMethod) Endpoint() (string, string) { return "", "/users/{id}" }
+
+func TestNewRequest_missingMethod(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	_, _, err := cli.Do(Discard, nil, NewRequest(testNoMethod{ID: "1"}))
+	if err == nil || !strings.Contains(err.Error(), "missing method") {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -36,6 +36,12 @@ type (
 	BodyCodecDecodeWrong interface {
 		DecodeWrong(r io.Reader, v any) error
 	}
+
+	// BodyCodecCheckResponseBody classifies the successful responses by their bodies,
+	// a non-nil error makes the response wrong, it is returned if the wrongV is nil.
+	BodyCodecCheckResponseBody interface {
+		CheckResponseBody(resp *http.Response, body []byte) error
+	}
 )
 
 type (
@@ -0,0 +1,155 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+var (
+	_ BodyCodec                     = (*bodyCodecEnvelope)(nil)
+	_ BodyCodecResponseIsSuccessful = (*bodyCodecEnvelope)(nil)
+	_ BodyCodecCheckResponseBody    = (*bodyCodecEnvelope)(nil)
+	_ BodyCodecDecodeWrong          = (*bodyCodecEnvelope)(nil)
+	_ BodyHeaderContentType         = (*bodyCodecEnvelope)(nil)
+	_ BodyHeaderContentLength       = (*bodyCodecEnvelope)(nil)
+	_ BodyHeaderAccept              = (*bodyCodecEnvelope)(nil)
+)
+
+// EnvelopeOptions are the field names and the success codes of the envelopes like {"code":0,"msg":"ok","data":{...}}.
+type EnvelopeOptions struct {
+	// CodeField defaults to "code".
+	CodeField string
+	// MessageField defaults to "msg".
+	MessageField string
+	// DataField defaults to "data".
+	DataField string
+	// SuccessCodes are the codes of the successful envelopes, defaults to "0".
+	// The codes are compared as text, so "0" matches both 0 and "0".
+	SuccessCodes []string
+	// AllowMissingCode makes a 2xx body without the code field successful,
+	// otherwise it is an *EnvelopeError with an empty Code.
+	AllowMissingCode bool
+}
+
+// EnvelopeError is the envelope of a successful response with a code other than the EnvelopeOptions.SuccessCodes,
+// it is returned if the wrongV is nil.
+type EnvelopeError struct {
+	// Code is the text of the code, see EnvelopeOptions.SuccessCodes.
+	Code    string
+	Message string
+	Data    json.RawMessage
+}
+
+func (e *EnvelopeError) Error() string {
+	code := "code " + e.Code
+	if e.Code == "" {
+		code = "without code"
+	}
+	if e.Message == "" {
+		return "envelope " + code
+	}
+	return fmt.Sprintf("envelope %s: %s", code, e.Message)
+}
+
+// NewBodyCodecEnvelope returns a JSON BodyCodec for the APIs responding with envelopes,
+// the success of a 2xx response is decided by the code of its envelope.
+//
+// The data of a successful envelope is decoded into the successV,
+// the whole envelope of a wrong 2xx response is decoded into the wrongV, or returned as *EnvelopeError if the wrongV is nil.
+// The other statuses are decoded into the wrongV like the successV.
+func NewBodyCodecEnvelope(opts EnvelopeOptions) BodyCodec {
+	if opts.CodeField == "" {
+		opts.CodeField = "code"
+	}
+	if opts.MessageField == "" {
+		opts.MessageField = "msg"
+	}
+	if opts.DataField == "" {
+		opts.DataField = "data"
+	}
+	if len(opts.SuccessCodes) == 0 {
+		opts.SuccessCodes = []string{"0"}
+	}
+	bcp := &bodyCodecEnvelope{opts: &opts}
+	bcp.pool = &sync.Pool{
+		New: func() any {
+			return &bodyCodecEnvelope{opts: bcp.opts, pool: bcp.pool}
+		},
+	}
+	return bcp
+}
+
+type bodyCodecEnvelope struct {
+	*bodyCodecJSON
+
+	opts *EnvelopeOptions
+	pool *sync.Pool
+}
+
+func (bcp *bodyCodecEnvelope) Get() BodyCodec {
+	bc := bcp.pool.Get().(*bodyCodecEnvelope)
+	bc.bodyCodecJSON = BodyCodecJSON.Get().(*bodyCodecJSON)
+	return bc
+}
+
+func (bcp *bodyCodecEnvelope) Put(bc BodyCodec) {
+	BodyCodecJSON.Put(bc.(*bodyCodecEnvelope).bodyCodecJSON)
+	bc.(*bodyCodecEnvelope).bodyCodecJSON = nil
+	bcp.pool.Put(bc)
+}
+
+// Decode decodes the data of the envelope into v, a missing or null data leaves v unchanged.
+func (bcp *bodyCodecEnvelope) Decode(r io.Reader, v any) error {
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&fields); err != nil {
+		return err
+	}
+	data, ok := fields[bcp.opts.DataField]
+	if !ok || bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("envelope %q: %w", bcp.opts.DataField, err)
+	}
+	return nil
+}
+
+// DecodeWrong decodes the whole envelope into v.
+func (bcp *bodyCodecEnvelope) DecodeWrong(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// CheckResponseBody returns *EnvelopeError if the code is not a success code or missing, see EnvelopeOptions.AllowMissingCode,
+// a body which is not a JSON object is left to Decode.
+func (bcp *bodyCodecEnvelope) CheckResponseBody(_ *http.Response, body []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil
+	}
+	code := ""
+	if raw, ok := fields[bcp.opts.CodeField]; ok {
+		code = string(bytes.TrimSpace(raw))
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			code = s
+		}
+		for _, c := range bcp.opts.SuccessCodes {
+			if code == c {
+				return nil
+			}
+		}
+	} else if bcp.opts.AllowMissingCode {
+		return nil
+	}
+
+	e := &EnvelopeError{Code: code}
+	_ = json.Unmarshal(fields[bcp.opts.MessageField], &e.Message)
+	if data, ok := fields[bcp.opts.DataField]; ok && !bytes.Equal(data, []byte("null")) {
+		e.Data = data
+	}
+	return e
+}
@@ -0,0 +1,84 @@
+package xhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestNewBodyCodecEnvelope(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			io.WriteString(w, `{"code":0,"msg":"ok","data":{"name":"a"}}`)
+		case "/wrong":
+			io.WriteString(w, `{"code":40001,"msg":"invalid token","data":{"retry":true}}`)
+		case "/custom":
+			io.WriteString(w, `{"status":"SUCCESS","message":"","result":{"name":"b"}}`)
+		case "/custom-wrong":
+			io.WriteString(w, `{"status":"FAILED","message":"busy"}`)
+		case "/error":
+			w.WriteHeader(http.StatusBadGateway)
+			io.WriteString(w, `{"code":502,"msg":"bad gateway","data":{"name":"gateway"}}`)
+		case "/no-code":
+			io.WriteString(w, `{"msg":"ok","data":{"name":"c"}}`)
+		}
+	})
+	cli := NewClient().WithHandler(handler).WithBodyCodec(NewBodyCodecEnvelope(EnvelopeOptions{}))
+
+	type data struct{ Name string }
+	type envelope struct {
+		Code int
+		Msg  string
+	}
+
+	var (
+		v     data
+		wrong envelope
+	)
+	if _, _, err := cli.Do(&v, &wrong, NewGet().Path("http://example.com/ok")); err != nil || v.Name != "a" || wrong.Code != 0 {
+		t.Fatalf("v = %+v, wrong = %+v, err = %v", v, wrong, err)
+	}
+
+	branch := DecodeBranchNone
+	if _, _, err := cli.Do(&v, &wrong, NewGet().Path("http://example.com/wrong").MatchedBranch(&branch)); err != nil ||
+		wrong.Code != 40001 || wrong.Msg != "invalid token" || branch != DecodeBranchWrong {
+		t.Fatalf("wrong = %+v, branch = %s, err = %v", wrong, branch, err)
+	}
+
+	_, respBody, err := cli.Do(&v, nil, NewGet().Path("http://example.com/wrong"))
+	var ee *EnvelopeError
+	if !errors.As(err, &ee) || ee.Code != "40001" || ee.Message != "invalid token" || string(ee.Data) != `{"retry":true}` || len(respBody) == 0 {
+		t.Fatalf("err = %v, body = %s", err, respBody)
+	}
+
+	var gateway data
+	if _, _, err = cli.Do(&v, &gateway, NewGet().Path("http://example.com/error")); err != nil || gateway.Name != "gateway" {
+		t.Fatalf("wrong = %+v, err = %v", gateway, err)
+	}
+
+	_, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com/no-code"))
+	if !errors.As(err, &ee) || ee.Code != "" || ee.Error() != "envelope without code: ok" {
+		t.Fatalf("err = %v", err)
+	}
+	v = data{}
+	cli.WithBodyCodec(NewBodyCodecEnvelope(EnvelopeOptions{AllowMissingCode: true}))
+	if _, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com/no-code")); err != nil || v.Name != "c" {
+		t.Fatalf("v = %+v, err = %v", v, err)
+	}
+
+	cli.WithBodyCodec(NewBodyCodecEnvelope(EnvelopeOptions{
+		CodeField:    "status",
+		MessageField: "message",
+		DataField:    "result",
+		SuccessCodes: []string{"SUCCESS"},
+	}))
+	if _, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com/custom")); err != nil || v.Name != "b" {
+		t.Fatalf("v = %+v, err = %v", v, err)
+	}
+	_, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com/custom-wrong"))
+	if !errors.As(err, &ee) || ee.Error() != "envelope code FAILED: busy" {
+		t.Fatalf("err = %v", err)
+	}
+}
@@ -18,6 +18,19 @@ type XClient struct {
 
 	doer          *http.Client
 	bodyCodecPool BodyCodec
+
+	digestAlgs         []string
+	digestVerification DigestVerification
+	signer             RequestSigner
+	verifier           ResponseVerifier
+
+	har      *HARRecorder
+	debug    *debugLogger
+	onTiming func(req *http.Request, t Timing)
+	metrics  MetricsRecorder
+	tracer   RequestTracer
+
+	validator Validator
 }
 
 func NewClient() *XClient {
@@ -28,12 +41,33 @@ func NewClient() *XClient {
 		WithBodyCodecJSON()
 }
 
+// ClientOption configures a client derived by XClient.With.
+type ClientOption func(xc *XClient)
+
+// Clone returns a copy of the client with every setting, the later changes of either client do not affect the other.
+// The collaborators like the Transport of the http.Client, the RequestSigner or the HARRecorder are shared.
 func (xc *XClient) Clone() *XClient {
-	return &XClient{
-		baseURL: xc.baseURL,
-		header:  xc.header.Clone(),
-		doer:    xc.doer,
+	c := *xc
+	c.header = xc.header.Clone()
+	c.digestAlgs = append([]string(nil), xc.digestAlgs...)
+	if xc.doer != nil {
+		doer := *xc.doer
+		c.doer = &doer
 	}
+	return &c
+}
+
+// With derives a client from a Clone of the client configured by opts, e.g. per tenant from a shared base:
+//
+//	tenant := base.With(func(xc *XClient) {
+//		xc.SetHeader("X-Tenant", "a").WithRequestTimeout(5 * time.Second)
+//	})
+func (xc *XClient) With(opts ...ClientOption) *XClient {
+	c := xc.Clone()
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (xc *XClient) WithBodyCodecJSON() *XClient {
@@ -93,65 +127,199 @@ func (xc *XClient) DoOnceWithBodyCodec(bodyCodec BodyCodec, successV, wrongV any
 	if successV == nil {
 		return nil, nil, errors.New("'successV' must not be nil")
 	}
+	validator := xc.validatorOf(xReq)
+	targets := xReq.statusTargets
+	targets.report(DecodeBranchNone)
+	respHeader := xReq.respHeader
 
 	var (
 		req    *http.Request
 		cancel context.CancelFunc
+		span   RequestSpan
 		bc     = bodyCodec.Get()
 	)
 	defer bodyCodec.Put(bc)
 
-	req, resp, cancel, err = xc.do(bc, xReq)
+	req, resp, cancel, span, err = xc.do(bc, xReq)
 	if err != nil {
 		cancel()
 		return nil, nil, err
 	}
+	defer func() { span.End(resp, err) }()
 	defer wrapCancelAndCloseRespBody(cancel, resp)()
 
 	if bc, ok := bc.(BodyCodecOnReceive); ok {
 		bc.OnReceive(req, resp)
 	}
+	if respHeader != nil {
+		// the body is read and decoded first, the error of the header is returned without another error
+		defer func() {
+			if herr := DecodeHeader(resp.Header, respHeader); herr != nil && err == nil {
+				err = wrapDecodeError(fmt.Errorf("decode header: %w", herr), resp)
+			}
+		}()
+	}
 
 	if resp.StatusCode == http.StatusNoContent {
-		return resp, nil, nil
+		return resp, nil, xc.verifyResponse(resp, nil)
 	}
 
 	if respBody, err = io.ReadAll(resp.Body); err != nil {
 		return resp, nil, fmt.Errorf("copy response body: %w", err)
 	}
+	if err = xc.verifyResponse(resp, respBody); err != nil {
+		return resp, respBody, err
+	}
+	if validator != nil {
+		if err = validator.ValidateResponse(resp, respBody); err != nil {
+			return resp, respBody, wrapDecodeError(fmt.Errorf("validate response: %w", err), resp)
+		}
+	}
+	problem := decodeProblem(bc, resp, respBody)
+	wrongBody := checkResponseBody(bc, resp, respBody)
+	if v, branch := targets.match(resp.StatusCode); branch != DecodeBranchNone {
+		targets.report(branch)
+		if v == Discard {
+			return resp, respBody, wrapProblem(problem, resp)
+		}
+		var decodeErr error
+		if isWrong(bc, resp) || wrongBody != nil || !isSuccessful(bc, resp) {
+			decodeErr = decodeWrong(bc, bytes.NewBuffer(respBody), v)
+		} else {
+			decodeErr = bc.Decode(bytes.NewBuffer(respBody), v)
+		}
+		if decodeErr != nil {
+			span.CodecError("decode", decodeErr)
+			return resp, respBody, wrapDecodeError(decodeErr, resp)
+		}
+		return resp, respBody, wrapProblem(problem, resp)
+	}
+
 	switch {
-	case isWrong(bc, resp):
+	case isWrong(bc, resp) || wrongBody != nil:
 		if wrongV == nil {
-			return resp, respBody, wrapDecodeError(nil, resp)
+			if wrongBody != nil {
+				return resp, respBody, wrapDecodeError(wrongBody, resp)
+			}
+			return resp, respBody, wrapDecodeError(problem, resp)
+		}
+		targets.report(DecodeBranchWrong)
+		if wrongV == Discard {
+			break
 		}
 		if err := decodeWrong(bc, bytes.NewBuffer(respBody), wrongV); err != nil {
+			span.CodecError("decode", err)
 			return resp, respBody, wrapDecodeError(err, resp)
 		}
 	case isSuccessful(bc, resp):
+		targets.report(DecodeBranchSuccess)
+		if successV == Discard {
+			break
+		}
 		if err := bc.Decode(bytes.NewBuffer(respBody), successV); err != nil {
+			span.CodecError("decode", err)
 			return resp, respBody, wrapDecodeError(err, resp)
 		}
 	default:
 		if wrongV == nil {
-			return resp, respBody, wrapDecodeError(nil, resp)
+			return resp, respBody, wrapDecodeError(problem, resp)
+		}
+		targets.report(DecodeBranchWrong)
+		if wrongV == Discard {
+			break
 		}
 		if err := bc.Decode(bytes.NewBuffer(respBody), wrongV); err != nil {
+			span.CodecError("decode", err)
 			return resp, respBody, wrapDecodeError(err, resp)
 		}
 	}
 
-	return
+	return resp, respBody, wrapProblem(problem, resp)
 }
 
 func (xc *XClient) DoWithRaw(xReq *XRequestBuilder) (req *http.Request, resp *http.Response, cancel context.CancelFunc, err error) {
 	codec := xc.bodyCodecPool.Get()
 	defer xc.bodyCodecPool.Put(codec)
-	req, resp, cancel, err = xc.do(codec, xReq)
+	var span RequestSpan
+	req, resp, cancel, span, err = xc.do(codec, xReq)
+	if err == nil && xc.tracer != nil {
+		resp.Body = &notifyBody{ReadCloser: resp.Body, finish: func() { span.End(resp, nil) }}
+	}
 	return req, resp, wrapCancelAndCloseRespBody(cancel, resp), err
 }
 
-func (xc *XClient) do(bc BodyCodec, xReq *XRequestBuilder) (req *http.Request, resp *http.Response, cancel context.CancelFunc, err error) {
+// do sends xReq, span is ended if err is not nil.
+func (xc *XClient) do(bc BodyCodec, xReq *XRequestBuilder) (req *http.Request, resp *http.Response, cancel context.CancelFunc, span RequestSpan, err error) {
+	xReq = xReq.sendable()
+	debug := xc.debug.enabled(xReq)
+	onTiming := xReq.onTiming
+	route := xReq.routeLabel()
+	validator := xc.validatorOf(xReq)
+
 	xc.initXReq(xReq)
+	span = xc.startSpan(xReq)
+
+	var tracer *timingTracer
+	if xc.onTiming != nil || onTiming != nil || xc.har != nil {
+		tracer = new(timingTracer)
+		xReq.tracer = tracer
+	}
+
+	if req, cancel, err = xc.prepare(bc, xReq); err != nil {
+		span.End(nil, err)
+		return
+	}
+	if validator != nil {
+		if err = validateRequest(validator, req); err != nil {
+			span.End(nil, err)
+			return
+		}
+	}
+
+	start := time.Now()
+	if debug {
+		xc.debug.logRequest(req)
+	}
+	var harRT *harRoundTrip
+	if xc.har != nil {
+		harRT = xc.har.begin(req, tracer)
+	}
+
+	var metricsRT *metricsRoundTrip
+	if xc.metrics != nil {
+		metricsRT = beginMetrics(xc.metrics, req, route)
+	}
+
+	if tracer != nil {
+		tracer.start = time.Now()
+	}
+	span.Request(req)
+	resp, err = xc.doer.Do(req)
+	if err != nil {
+		span.End(nil, err)
+	}
+	if harRT != nil {
+		harRT.end(resp, err)
+	}
+	if metricsRT != nil {
+		metricsRT.end(resp, err)
+	}
+	if debug {
+		xc.debug.logResponse(req, resp, err, start)
+	}
+	if xc.onTiming != nil || onTiming != nil {
+		var fn func(req *http.Request, t Timing)
+		if onTiming != nil {
+			fn = func(_ *http.Request, t Timing) { onTiming(t) }
+		}
+		tracer.report(req, resp, xc.onTiming, fn)
+	}
+
+	return
+}
+
+// prepare builds the request exactly as it will be sent, xReq must be initialized by initXReq.
+func (xc *XClient) prepare(bc BodyCodec, xReq *XRequestBuilder) (req *http.Request, cancel context.CancelFunc, err error) {
 	if req, cancel, err = xReq.build(bc); err != nil {
 		return
 	}
@@ -160,13 +328,35 @@ func (xc *XClient) do(bc BodyCodec, xReq *XRequestBuilder) (req *http.Request, r
 		bc.OnSend(req)
 	}
 
-	if resp, err = xc.doer.Do(req); err != nil {
-		return
+	if len(xc.digestAlgs) != 0 {
+		if err = setContentDigest(req, xc.digestAlgs); err != nil {
+			err = fmt.Errorf("content digest: %w", err)
+			return
+		}
+	}
+	if xc.signer != nil {
+		if err = signRequest(xc.signer, req); err != nil {
+			err = fmt.Errorf("sign request: %w", err)
+			return
+		}
 	}
 
 	return
 }
 
+func (xc *XClient) verifyResponse(resp *http.Response, respBody []byte) error {
+	if err := verifyDigest(resp, respBody, xc.digestVerification); err != nil {
+		return wrapDecodeError(err, resp)
+	}
+	if xc.verifier == nil {
+		return nil
+	}
+	if err := xc.verifier.VerifyResponse(resp, respBody); err != nil {
+		return wrapDecodeError(err, resp)
+	}
+	return nil
+}
+
 func (xc *XClient) initXReq(xReq *XRequestBuilder) {
 	xReq.baseURL = xc.baseURL
 	if xReq.timeout <= 0 {
@@ -208,6 +398,15 @@ func decodeWrong(bc BodyCodec, r io.Reader, wrongV any) error {
 	return bcDW.DecodeWrong(r, wrongV)
 }
 
+// checkResponseBody returns the error of BodyCodecCheckResponseBody for a successful response.
+func checkResponseBody(bc BodyCodec, resp *http.Response, respBody []byte) error {
+	check, ok := bc.(BodyCodecCheckResponseBody)
+	if !ok || isWrong(bc, resp) || !isSuccessful(bc, resp) {
+		return nil
+	}
+	return check.CheckResponseBody(resp, respBody)
+}
+
 func isSuccessful(bc BodyCodec, resp *http.Response) bool {
 	if condition, ok := bc.(BodyCodecResponseIsSuccessful); ok {
 		return condition.IsSuccessful(resp)
@@ -216,6 +415,14 @@ func isSuccessful(bc BodyCodec, resp *http.Response) bool {
 	}
 }
 
+// wrapProblem wraps the *ProblemDetails of decodeProblem, a nil problem is nil.
+func wrapProblem(problem error, resp *http.Response) error {
+	if problem == nil {
+		return nil
+	}
+	return wrapDecodeError(problem, resp)
+}
+
 func wrapDecodeError(err error, resp *http.Response) error {
 	if err != nil {
 		return fmt.Errorf("unexpected error: URL: %s (%d %s): %w", resp.Request.URL, resp.StatusCode, http.StatusText(resp.StatusCode), err)
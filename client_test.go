@@ -2,14 +2,19 @@ package xhttpclient
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	urlpkg "net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func BenchmarkXClient_Do(b *testing.B) {
@@ -182,3 +187,88 @@ func TestXClient_Do_gzip(t *testing.T) {
 
 	t.Logf("raw response: 👇\n%s", respBody)
 }
+
+func TestXClient_Do_Discard(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		w.Write([]byte("not json"))
+	}))
+
+	for _, code := range []int{http.StatusOK, http.StatusNotFound} {
+		resp, respBody, err := cli.Do(Discard, Discard, NewGet().Path("http://example.com").SetQuery("code", strconv.Itoa(code)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != code || string(respBody) != "not json" {
+			t.Fatalf("status = %d, body = %q", resp.StatusCode, respBody)
+		}
+	}
+}
+
+func TestXClient_Clone(t *testing.T) {
+	base := NewClient().
+		BaseURL("https://example.com").
+		SetHeader("X-Tenant", "base").
+		WithRequestTimeout(time.Second).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `"`+r.Header.Get("X-Tenant")+`"`)
+		})).
+		WithBodyCodec(BodyCodecFormUrlencodedAndJSON).
+		WithContentDigest(DigestSHA256).
+		WithDigestVerification(DigestVerificationIfPresent).
+		WithRequestSigner(NewHMACSigner([]byte("key"))).
+		WithResponseVerifier(NewMessageVerifier()).
+		WithHARRecorder(NewHARRecorder()).
+		WithDebug(slog.NewTextHandler(io.Discard, nil), nil).
+		WithTiming(func(req *http.Request, t Timing) {}).
+		WithMetricsRecorder(new(testMetricsRecorder)).
+		WithRequestTracer(new(testTracer)).
+		WithValidator(JSONSchemaValidator{})
+
+	// every setting is copied, including the ones added later
+	clone := base.Clone()
+	bv, cv := reflect.ValueOf(base).Elem(), reflect.ValueOf(clone).Elem()
+	for i := 0; i < bv.NumField(); i++ {
+		name := bv.Type().Field(i).Name
+		f, cf := bv.Field(i), cv.Field(i)
+		if f.IsZero() {
+			t.Errorf("%s is not configured by the test", name)
+			continue
+		}
+		switch {
+		case name == "doer":
+			if f.Pointer() == cf.Pointer() || base.doer.Transport != clone.doer.Transport {
+				t.Errorf("%s is shared or not copied", name)
+			}
+		case f.Kind() == reflect.Func:
+			if f.Pointer() != cf.Pointer() {
+				t.Errorf("%s is not copied", name)
+			}
+		case f.Kind() == reflect.Map || f.Kind() == reflect.Slice:
+			if f.Pointer() == cf.Pointer() || fmt.Sprint(f) != fmt.Sprint(cf) {
+				t.Errorf("%s is shared or not copied", name)
+			}
+		default:
+			if !f.Equal(cf) {
+				t.Errorf("%s is not copied", name)
+			}
+		}
+	}
+
+	tenant := base.With(
+		func(xc *XClient) { xc.SetHeader("X-Tenant", "a") },
+		func(xc *XClient) {
+			xc.WithBodyCodecJSON().WithDigestVerification(DigestVerificationOff).WithResponseVerifier(nil)
+		},
+	)
+	tenant.digestAlgs[0] = DigestSHA512
+	var got string
+	if _, _, err := tenant.Do(&got, nil, NewGet()); err != nil || got != "a" {
+		t.Fatalf("got = %q, err = %v", got, err)
+	}
+	if base.header.Get("X-Tenant") != "base" || base.bodyCodecPool != BodyCodecFormUrlencodedAndJSON ||
+		base.digestVerification != DigestVerificationIfPresent || base.digestAlgs[0] != DigestSHA256 {
+		t.Fatal("the derived client changed the base")
+	}
+}
@@ -0,0 +1,577 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The methods of the interface are annotated in their doc comments:
+//
+//	type GitHub interface {
+//		// GetRepo returns a repository.
+//		//
+//		// xhttp:GET /repos/{owner}/{repo}
+//		// xhttp:error *APIError
+//		GetRepo(ctx context.Context, owner, repo string) (*Repo, error)
+//
+//		// xhttp:GET /search/repositories
+//		// xhttp:query q
+//		// xhttp:query perPage per_page
+//		// xhttp:header tenant X-Tenant
+//		SearchRepos(ctx context.Context, q string, perPage int, tenant string) (SearchResult, error)
+//
+//		// xhttp:POST /repos/{owner}/{repo}/issues
+//		// xhttp:body issue
+//		CreateIssue(ctx context.Context, owner, repo string, issue *Issue) (*Issue, error)
+//	}
+//
+// Annotations:
+//
+//	xhttp:METHOD /path/{param}   the method and the path template, the parameters named after the template are PathParam
+//	xhttp:query param [name]     the query parameter, a slice adds a value per element, a nil pointer is omitted
+//	xhttp:queries param          QueryStruct(param)
+//	xhttp:header param Name      the header, a slice adds a value per element, a nil pointer is omitted
+//	xhttp:body param             Body(param)
+//	xhttp:bind param             Bind(param)
+//	xhttp:error Type             decodes the unsuccessful response into Type, it must implement error
+//
+// A context.Context parameter is the request context, every other parameter must be annotated.
+// The results are either error, or a decoded value and error.
+
+const annotationPrefix = "xhttp:"
+
+var _builderFuncs = map[string]string{
+	"GET":     "NewGet",
+	"HEAD":    "NewHead",
+	"POST":    "NewPost",
+	"PUT":     "NewPut",
+	"PATCH":   "NewPatch",
+	"DELETE":  "NewDelete",
+	"CONNECT": "NewConnect",
+	"OPTIONS": "NewOptions",
+	"TRACE":   "NewTrace",
+}
+
+var _pathParamRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func runIface(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("iface", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	typeName := flags.String("type", "", "interface type name, required")
+	impl := flags.String("impl", "", "implementation type name, default is <type>Client with a lower case initial, the constructor is New<type>Client")
+	output := flags.String("output", "", "output file, default is <type>_xhttp.go in lower case")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *typeName == "" {
+		flags.Usage()
+		return errors.New("-type is required")
+	}
+
+	dir := "."
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(*typeName)+"_xhttp.go")
+	}
+
+	src, err := generateIface(dir, *typeName, *impl, filepath.Base(*output))
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
+
+type ifaceParam struct {
+	name string
+	typ  string
+}
+
+type ifaceNamedParam struct {
+	param string
+	name  string
+}
+
+type ifaceMethod struct {
+	name   string
+	params []ifaceParam
+	result string // empty if the only result is error
+
+	ctx          string
+	method       string
+	path         string
+	pathParams   []string
+	queries      []ifaceNamedParam
+	queryStructs []string
+	headers      []ifaceNamedParam
+	body         string
+	binds        []string
+	errType      string
+}
+
+type ifaceGenerator struct {
+	pkgName  string
+	typeName string
+	impl     string
+	methods  []*ifaceMethod
+	imports  map[string]string // name -> path of the source file imports
+	usedPkgs map[string]bool
+	needFmt  bool
+}
+
+func generateIface(dir, typeName, impl, outputBase string) ([]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == outputBase {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	g := &ifaceGenerator{typeName: typeName, impl: impl, usedPkgs: make(map[string]bool)}
+	if g.impl == "" {
+		g.impl = lowerFirst(typeName) + "Client"
+	}
+
+	var iface *ast.InterfaceType
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			ts, ok := n.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != typeName {
+				return true
+			}
+			if it, ok := ts.Type.(*ast.InterfaceType); ok && ts.TypeParams == nil {
+				iface = it
+				g.pkgName = file.Name.Name
+				g.imports = fileImports(file)
+			}
+			return false
+		})
+	}
+	if iface == nil {
+		return nil, fmt.Errorf("non-generic interface %s not found in %s", typeName, dir)
+	}
+
+	for _, field := range iface.Methods.List {
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok || len(field.Names) != 1 {
+			return nil, fmt.Errorf("%s: embedded interfaces are not supported", fset.Position(field.Pos()))
+		}
+		m, err := g.parseMethod(field.Names[0].Name, ft, field.Doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", fset.Position(field.Pos()), field.Names[0].Name, err)
+		}
+		g.methods = append(g.methods, m)
+	}
+
+	return g.generate()
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		name := pathPackageName(path)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = path
+	}
+	return imports
+}
+
+// pathPackageName guesses the package name of path, e.g. "gopkg.in/yaml.v3" is "yaml".
+func pathPackageName(path string) string {
+	name := path[strings.LastIndex(path, "/")+1:]
+	if i := strings.IndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	if strings.HasPrefix(name, "v") && len(name) > 1 && strings.Trim(name[1:], "0123456789") == "" {
+		if i := strings.LastIndex(path, "/"); i > 0 {
+			return pathPackageName(path[:i])
+		}
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func (g *ifaceGenerator) parseMethod(name string, ft *ast.FuncType, doc *ast.CommentGroup) (*ifaceMethod, error) {
+	m := &ifaceMethod{name: name}
+
+	for _, field := range ft.Params.List {
+		if len(field.Names) == 0 {
+			return nil, errors.New("parameters must be named")
+		}
+		if _, ok := field.Type.(*ast.Ellipsis); ok {
+			return nil, errors.New("variadic parameters are not supported")
+		}
+		typ := g.typeString(field.Type)
+		for _, ident := range field.Names {
+			if typ == "context.Context" {
+				m.ctx = ident.Name
+				continue
+			}
+			m.params = append(m.params, ifaceParam{name: ident.Name, typ: typ})
+		}
+	}
+
+	var results []string
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for i := 0; i < n; i++ {
+				results = append(results, g.typeString(field.Type))
+			}
+		}
+	}
+	switch {
+	case len(results) == 1 && results[0] == "error":
+	case len(results) == 2 && results[1] == "error":
+		m.result = results[0]
+	default:
+		return nil, errors.New("results must be (error) or (T, error)")
+	}
+
+	if doc != nil {
+		for _, c := range doc.List {
+			line := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(c.Text, "//"), "/*"))
+			if !strings.HasPrefix(line, annotationPrefix) {
+				continue
+			}
+			if err := g.parseAnnotation(m, strings.Fields(strings.TrimPrefix(line, annotationPrefix))); err != nil {
+				return nil, fmt.Errorf("%q: %w", line, err)
+			}
+		}
+	}
+	if m.method == "" {
+		return nil, errors.New("missing the xhttp:METHOD /path annotation")
+	}
+
+	used := make(map[string]bool)
+	for _, p := range m.pathParams {
+		used[p] = true
+	}
+	for _, q := range m.queries {
+		used[q.param] = true
+	}
+	for _, h := range m.headers {
+		used[h.param] = true
+	}
+	for _, p := range append(append(m.queryStructs, m.binds...), m.body) {
+		used[p] = true
+	}
+	for p := range used {
+		if p != "" && m.param(p) == nil {
+			return nil, fmt.Errorf("unknown parameter '%s'", p)
+		}
+	}
+	for _, p := range m.params {
+		if !used[p.name] {
+			return nil, fmt.Errorf("parameter '%s' is not annotated", p.name)
+		}
+	}
+	for _, p := range m.pathParams {
+		switch typ := m.param(p).typ; {
+		case strings.HasPrefix(typ, "[]"):
+			return nil, fmt.Errorf("path parameter '%s' must not be a slice", p)
+		case strings.HasPrefix(typ, "*"):
+			return nil, fmt.Errorf("path parameter '%s' must not be a pointer", p)
+		}
+	}
+	for _, q := range append(append([]ifaceNamedParam(nil), m.queries...), m.headers...) {
+		typ := strings.TrimPrefix(m.param(q.param).typ, "[]")
+		if elem, ok := strings.CutPrefix(typ, "*"); ok && (strings.HasPrefix(elem, "*") || strings.HasPrefix(elem, "[]")) {
+			return nil, fmt.Errorf("parameter '%s' must be a pointer to a value", q.param)
+		}
+	}
+	return m, nil
+}
+
+func (g *ifaceGenerator) parseAnnotation(m *ifaceMethod, fields []string) error {
+	if len(fields) == 0 {
+		return errors.New("empty annotation")
+	}
+	key, args := fields[0], fields[1:]
+	nargs := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("%s expects %d to %d arguments", key, min, max)
+		}
+		return nil
+	}
+
+	if _, ok := _builderFuncs[key]; ok {
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		if m.method != "" {
+			return errors.New("duplicate method")
+		}
+		m.method, m.path = key, args[0]
+		for _, sub := range _pathParamRegexp.FindAllStringSubmatch(m.path, -1) {
+			m.pathParams = append(m.pathParams, sub[1])
+		}
+		return nil
+	}
+
+	switch key {
+	case "query":
+		if err := nargs(1, 2); err != nil {
+			return err
+		}
+		q := ifaceNamedParam{param: args[0], name: args[0]}
+		if len(args) == 2 {
+			q.name = args[1]
+		}
+		m.queries = append(m.queries, q)
+	case "queries":
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		m.queryStructs = append(m.queryStructs, args[0])
+	case "header":
+		if err := nargs(2, 2); err != nil {
+			return err
+		}
+		m.headers = append(m.headers, ifaceNamedParam{param: args[0], name: args[1]})
+	case "body":
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		if m.body != "" {
+			return errors.New("duplicate body")
+		}
+		m.body = args[0]
+	case "bind":
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		m.binds = append(m.binds, args[0])
+	case "error":
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		expr, err := parser.ParseExpr(args[0])
+		if err != nil {
+			return err
+		}
+		m.errType = g.typeString(expr)
+	default:
+		return fmt.Errorf("unknown annotation '%s'", key)
+	}
+	return nil
+}
+
+func (m *ifaceMethod) param(name string) *ifaceParam {
+	for i := range m.params {
+		if m.params[i].name == name {
+			return &m.params[i]
+		}
+	}
+	return nil
+}
+
+// typeString prints expr and records the imported packages it refers to.
+func (g *ifaceGenerator) typeString(expr ast.Expr) string {
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				g.usedPkgs[ident.Name] = true
+			}
+			return false
+		}
+		return true
+	})
+	return types.ExprString(expr)
+}
+
+func (g *ifaceGenerator) generate() ([]byte, error) {
+	var body bytes.Buffer
+	for _, m := range g.methods {
+		g.writeMethod(&body, m)
+	}
+
+	imports := map[string]string{"xhttpclient": "github.com/electricbubble/xhttpclient"}
+	if g.needFmt {
+		imports["fmt"] = "fmt"
+	}
+	for name := range g.usedPkgs {
+		path, ok := g.imports[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown package '%s'", name)
+		}
+		imports[name] = path
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by xhttpgen iface -type %s; DO NOT EDIT.\n\n", g.typeName)
+	fmt.Fprintf(&buf, "package %s\n\n", g.pkgName)
+	writeImports(&buf, imports)
+
+	fmt.Fprintf(&buf, "type %s struct {\n\txc *xhttpclient.XClient\n}\n\n", g.impl)
+	fmt.Fprintf(&buf, "var _ %s = (*%s)(nil)\n\n", g.typeName, g.impl)
+	fmt.Fprintf(&buf, "// New%sClient implements %s with xc.\n", g.typeName, g.typeName)
+	fmt.Fprintf(&buf, "func New%sClient(xc *xhttpclient.XClient) %s {\n\treturn &%s{xc: xc}\n}\n\n", g.typeName, g.typeName, g.impl)
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+func (g *ifaceGenerator) writeMethod(w *bytes.Buffer, m *ifaceMethod) {
+	params := make([]string, 0, len(m.params)+1)
+	if m.ctx != "" {
+		params = append(params, m.ctx+" context.Context")
+	}
+	for _, p := range m.params {
+		params = append(params, p.name+" "+p.typ)
+	}
+	results := "error"
+	if m.result != "" {
+		results = "(" + m.result + ", error)"
+	}
+	fmt.Fprintf(w, "func (c *%s) %s(%s) %s {\n", g.impl, m.name, strings.Join(params, ", "), results)
+
+	fmt.Fprintf(w, "xReq := xhttpclient.%s()", _builderFuncs[m.method])
+	if m.ctx != "" {
+		fmt.Fprintf(w, ".\nWithContext(%s)", m.ctx)
+	}
+	for _, p := range m.binds {
+		fmt.Fprintf(w, ".\nBind(%s)", p)
+	}
+	fmt.Fprintf(w, ".\nPathTemplate(%q)", m.path)
+	for _, p := range m.pathParams {
+		fmt.Fprintf(w, ".\nPathParam(%q, %s)", p, g.stringExpr(p, m.param(p).typ))
+	}
+	for _, p := range m.queryStructs {
+		fmt.Fprintf(w, ".\nQueryStruct(%s)", p)
+	}
+	if m.body != "" {
+		fmt.Fprintf(w, ".\nBody(%s)", m.body)
+	}
+	fmt.Fprintf(w, "\n")
+
+	for _, q := range m.queries {
+		g.writeValues(w, "AddQuery", q, m.param(q.param).typ)
+	}
+	for _, h := range m.headers {
+		g.writeValues(w, "AddHeader", h, m.param(h.param).typ)
+	}
+	fmt.Fprintf(w, "\n")
+
+	zero := "nil"
+	successV := "xhttpclient.Discard"
+	switch {
+	case m.result == "":
+	case strings.HasPrefix(m.result, "*"):
+		fmt.Fprintf(w, "out := new(%s)\n", m.result[1:])
+		successV = "out"
+	default:
+		fmt.Fprintf(w, "var out %s\n", m.result)
+		successV = "&out"
+		zero = "out"
+	}
+	wrongV := "nil"
+	switch {
+	case m.errType == "":
+	case strings.HasPrefix(m.errType, "*"):
+		fmt.Fprintf(w, "wrong := new(%s)\n", m.errType[1:])
+		wrongV = "wrong"
+	default:
+		fmt.Fprintf(w, "var wrong %s\n", m.errType)
+		wrongV = "&wrong"
+	}
+
+	ret := func(v string) string {
+		if m.result == "" {
+			return "return " + v + "\n"
+		}
+		return "return " + zero + ", " + v + "\n"
+	}
+	if m.errType == "" {
+		fmt.Fprintf(w, "if _, _, err := c.xc.Do(%s, %s, xReq); err != nil {\n%s}\n", successV, wrongV, ret("err"))
+	} else {
+		fmt.Fprintf(w, "resp, _, err := c.xc.Do(%s, %s, xReq)\nif err != nil {\n%s}\n", successV, wrongV, ret("err"))
+		fmt.Fprintf(w, "if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {\n%s}\n", ret("wrong"))
+	}
+
+	if m.result == "" {
+		fmt.Fprintf(w, "return nil\n}\n\n")
+	} else {
+		fmt.Fprintf(w, "return out, nil\n}\n\n")
+	}
+}
+
+// writeValues adds param as the values of the query parameter or the header.
+func (g *ifaceGenerator) writeValues(w *bytes.Buffer, add string, p ifaceNamedParam, typ string) {
+	if elem, ok := strings.CutPrefix(typ, "[]"); ok {
+		fmt.Fprintf(w, "for _, v := range %s {\n", p.param)
+		g.writeValue(w, add, p.name, "v", elem)
+		fmt.Fprintf(w, "}\n")
+		return
+	}
+	g.writeValue(w, add, p.name, p.param, typ)
+}
+
+// writeValue adds the value v, a nil pointer is omitted.
+func (g *ifaceGenerator) writeValue(w *bytes.Buffer, add, name, v, typ string) {
+	if elem, ok := strings.CutPrefix(typ, "*"); ok {
+		fmt.Fprintf(w, "if %s != nil {\nxReq.%s(%q, %s)\n}\n", v, add, name, g.stringExpr("*"+v, elem))
+		return
+	}
+	fmt.Fprintf(w, "xReq.%s(%q, %s)\n", add, name, g.stringExpr(v, typ))
+}
+
+func (g *ifaceGenerator) stringExpr(name, typ string) string {
+	switch typ {
+	case "string":
+		return name
+	case "time.Time":
+		if strings.HasPrefix(name, "*") {
+			name = "(" + name + ")"
+		}
+		return name + ".Format(time.RFC3339)"
+	}
+	g.needFmt = true
+	return "fmt.Sprint(" + name + ")"
+}
+
+// lowerFirst lowers the leading initialism or letter, e.g. "APIClient" is "apiClient".
+func lowerFirst(s string) string {
+	rs := []rune(s)
+	for i := range rs {
+		if !unicode.IsUpper(rs[i]) || i > 0 && i+1 < len(rs) && unicode.IsLower(rs[i+1]) {
+			break
+		}
+		rs[i] = unicode.ToLower(rs[i])
+	}
+	return string(rs)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateIface(t *testing.T) {
+	dir := filepath.Join("internal", "ifacetest")
+	got, err := generateIface(dir, "API", "", "api_xhttp.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "api_xhttp.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s/api_xhttp.go is outdated, run go generate:\n%s", dir, got)
+	}
+}
+
+func TestGenerateIface_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{
+			name:    "not_found",
+			src:     `type Other interface{}`,
+			wantErr: "interface API not found",
+		},
+		{
+			name: "missing_method",
+			src: `type API interface {
+				Get(id string) error
+			}`,
+			wantErr: "missing the xhttp:METHOD /path annotation",
+		},
+		{
+			name: "not_annotated",
+			src: `type API interface {
+				// xhttp:GET /users
+				Get(id string) error
+			}`,
+			wantErr: "parameter 'id' is not annotated",
+		},
+		{
+			name: "unknown_param",
+			src: `type API interface {
+				// xhttp:GET /users/{id}
+				Get() error
+			}`,
+			wantErr: "unknown parameter 'id'",
+		},
+		{
+			name: "unknown_annotation",
+			src: `type API interface {
+				// xhttp:GET /users
+				// xhttp:cookie id
+				Get(id string) error
+			}`,
+			wantErr: "unknown annotation 'cookie'",
+		},
+		{
+			name: "results",
+			src: `type API interface {
+				// xhttp:GET /users
+				Get() (string, int)
+			}`,
+			wantErr: "results must be (error) or (T, error)",
+		},
+		{
+			name: "slice_path",
+			src: `type API interface {
+				// xhttp:GET /users/{ids}
+				Get(ids []string) error
+			}`,
+			wantErr: "path parameter 'ids' must not be a slice",
+		},
+		{
+			name: "pointer_path",
+			src: `type API interface {
+				// xhttp:GET /users/{id}
+				Get(id *string) error
+			}`,
+			wantErr: "path parameter 'id' must not be a pointer",
+		},
+		{
+			name: "pointer_slice",
+			src: `type API interface {
+				// xhttp:GET /users
+				// xhttp:query ids
+				Get(ids *[]string) error
+			}`,
+			wantErr: "parameter 'ids' must be a pointer to a value",
+		},
+		{
+			name: "embedded",
+			src: `type API interface {
+				Other
+			}`,
+			wantErr: "embedded interfaces are not supported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "api.go"), []byte("package api\n\n"+tt.src+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := generateIface(dir, "API", "", "api_xhttp.go")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLowerFirst(t *testing.T) {
+	for s, want := range map[string]string{
+		"API":        "api",
+		"GitHub":     "gitHub",
+		"HTTPClient": "httpClient",
+		"x":          "x",
+	} {
+		if got := lowerFirst(s); got != want {
+			t.Errorf("lowerFirst(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package ifacetest is implemented by xhttpgen iface, api_xhttp.go is compared with the generated source by the tests.
+package ifacetest
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate go run github.com/electricbubble/xhttpclient/cmd/xhttpgen iface -type API
+
+type API interface {
+	// GetRepo returns a repository.
+	//
+	// xhttp:GET /repos/{owner}/{repo}
+	// xhttp:error *APIError
+	GetRepo(ctx context.Context, owner, repo string) (*Repo, error)
+
+	// xhttp:GET /repos
+	// xhttp:query since
+	// xhttp:query perPage per_page
+	// xhttp:query page
+	// xhttp:query topics topic
+	// xhttp:header tenant X-Tenant
+	ListRepos(ctx context.Context, since time.Time, perPage int, page *int, topics []string, tenant string) ([]Repo, error)
+
+	// xhttp:GET /search
+	// xhttp:queries filter
+	Search(filter SearchFilter) (SearchResult, error)
+
+	// xhttp:POST /repos/{owner}/issues
+	// xhttp:body issue
+	// xhttp:error APIError
+	CreateIssue(ctx context.Context, owner string, issue *Issue) (*Issue, error)
+
+	// xhttp:DELETE /repos/{owner}/{repo}/issues/{number}
+	DeleteIssue(ctx context.Context, owner, repo string, number int) error
+
+	// xhttp:PATCH /repos/{owner}/{repo}
+	// xhttp:bind update
+	UpdateRepo(ctx context.Context, owner, repo string, update RepoUpdate) (*Repo, error)
+}
+
+type Repo struct {
+	Owner string `json:"owner"`
+	Name  string `json:"name"`
+}
+
+type Issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+type SearchFilter struct {
+	Q    string `query:"q"`
+	Page int    `query:"page,omitempty"`
+}
+
+type SearchResult struct {
+	Total int    `json:"total"`
+	Items []Repo `json:"items"`
+}
+
+type RepoUpdate struct {
+	Tenant string `header:"X-Tenant,omitempty"`
+	Body   struct {
+		Name string `json:"name"`
+	} `body:""`
+}
+
+type APIError struct {
+	Message string `json:"message"`
+}
+
+func (e APIError) Error() string {
+	return e.Message
+}
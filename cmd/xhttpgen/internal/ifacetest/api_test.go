@@ -0,0 +1,89 @@
+package ifacetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+func TestNewAPIClient(t *testing.T) {
+	var last *http.Request
+	var lastBody string
+	api := NewAPIClient(xhttpclient.NewClient().
+		BaseURL("https://api.example.com").
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			last, lastBody = r, string(body)
+			switch {
+			case r.URL.Path == "/repos/o/missing":
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"message":"Not Found"}`)
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			case r.URL.Path == "/repos":
+				io.WriteString(w, `[{"owner":"o","name":"a"},{"owner":"o","name":"b"}]`)
+			case r.URL.Path == "/search":
+				io.WriteString(w, `{"total":1,"items":[{"name":"a"}]}`)
+			case r.URL.Path == "/repos/o/issues":
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				io.WriteString(w, `{"message":"invalid"}`)
+			default:
+				io.WriteString(w, `{"owner":"o","name":"a/b"}`)
+			}
+		})))
+	ctx := context.Background()
+
+	repo, err := api.GetRepo(ctx, "o", "a/b")
+	if err != nil || repo.Name != "a/b" || last.URL.EscapedPath() != "/repos/o/a%2Fb" {
+		t.Fatalf("repo = %+v, err = %v, path = %s", repo, err, last.URL.EscapedPath())
+	}
+	_, err = api.GetRepo(ctx, "o", "missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "Not Found" {
+		t.Fatalf("err = %#v", err)
+	}
+
+	repos, err := api.ListRepos(ctx, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 50, nil, []string{"go", "http"}, "t1")
+	if err != nil || len(repos) != 2 {
+		t.Fatalf("repos = %+v, err = %v", repos, err)
+	}
+	if q := last.URL.Query(); q.Get("since") != "2024-01-02T03:04:05Z" || q.Get("per_page") != "50" || q.Has("page") || len(q["topic"]) != 2 ||
+		last.Header.Get("X-Tenant") != "t1" {
+		t.Fatalf("query = %v, header = %v", q, last.Header)
+	}
+	page := 2
+	if _, err = api.ListRepos(ctx, time.Time{}, 50, &page, nil, ""); err != nil || last.URL.Query().Get("page") != "2" {
+		t.Fatalf("err = %v, query = %s", err, last.URL.RawQuery)
+	}
+
+	result, err := api.Search(SearchFilter{Q: "x"})
+	if err != nil || result.Total != 1 || last.URL.RawQuery != "q=x" {
+		t.Fatalf("result = %+v, err = %v, query = %s", result, err, last.URL.RawQuery)
+	}
+
+	_, err = api.CreateIssue(ctx, "o", &Issue{Title: "t"})
+	var apiErrV APIError
+	if !errors.As(err, &apiErrV) || apiErrV.Message != "invalid" || lastBody != `{"number":0,"title":"t"}`+"\n" {
+		t.Fatalf("err = %#v, body = %q", err, lastBody)
+	}
+
+	if err = api.DeleteIssue(ctx, "o", "a", 7); err != nil || last.URL.Path != "/repos/o/a/issues/7" {
+		t.Fatalf("err = %v, path = %s", err, last.URL.Path)
+	}
+
+	update := RepoUpdate{Tenant: "t2"}
+	update.Body.Name = "c"
+	if _, err = api.UpdateRepo(ctx, "o", "a", update); err != nil || last.Method != http.MethodPatch || last.Header.Get("X-Tenant") != "t2" {
+		t.Fatalf("err = %v, request = %s %v", err, last.Method, last.Header)
+	}
+	var body map[string]string
+	if json.Unmarshal([]byte(lastBody), &body); body["name"] != "c" {
+		t.Fatalf("body = %q", lastBody)
+	}
+}
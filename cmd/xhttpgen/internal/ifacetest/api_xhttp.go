@@ -0,0 +1,122 @@
+// Code generated by xhttpgen iface -type API; DO NOT EDIT.
+
+package ifacetest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+type apiClient struct {
+	xc *xhttpclient.XClient
+}
+
+var _ API = (*apiClient)(nil)
+
+// NewAPIClient implements API with xc.
+func NewAPIClient(xc *xhttpclient.XClient) API {
+	return &apiClient{xc: xc}
+}
+
+func (c *apiClient) GetRepo(ctx context.Context, owner string, repo string) (*Repo, error) {
+	xReq := xhttpclient.NewGet().
+		WithContext(ctx).
+		PathTemplate("/repos/{owner}/{repo}").
+		PathParam("owner", owner).
+		PathParam("repo", repo)
+
+	out := new(Repo)
+	wrong := new(APIError)
+	resp, _, err := c.xc.Do(out, wrong, xReq)
+	if err != nil {
+		return nil, err
+	}
+	if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, wrong
+	}
+	return out, nil
+}
+
+func (c *apiClient) ListRepos(ctx context.Context, since time.Time, perPage int, page *int, topics []string, tenant string) ([]Repo, error) {
+	xReq := xhttpclient.NewGet().
+		WithContext(ctx).
+		PathTemplate("/repos")
+	xReq.AddQuery("since", since.Format(time.RFC3339))
+	xReq.AddQuery("per_page", fmt.Sprint(perPage))
+	if page != nil {
+		xReq.AddQuery("page", fmt.Sprint(*page))
+	}
+	for _, v := range topics {
+		xReq.AddQuery("topic", v)
+	}
+	xReq.AddHeader("X-Tenant", tenant)
+
+	var out []Repo
+	if _, _, err := c.xc.Do(&out, nil, xReq); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) Search(filter SearchFilter) (SearchResult, error) {
+	xReq := xhttpclient.NewGet().
+		PathTemplate("/search").
+		QueryStruct(filter)
+
+	var out SearchResult
+	if _, _, err := c.xc.Do(&out, nil, xReq); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func (c *apiClient) CreateIssue(ctx context.Context, owner string, issue *Issue) (*Issue, error) {
+	xReq := xhttpclient.NewPost().
+		WithContext(ctx).
+		PathTemplate("/repos/{owner}/issues").
+		PathParam("owner", owner).
+		Body(issue)
+
+	out := new(Issue)
+	var wrong APIError
+	resp, _, err := c.xc.Do(out, &wrong, xReq)
+	if err != nil {
+		return nil, err
+	}
+	if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, wrong
+	}
+	return out, nil
+}
+
+func (c *apiClient) DeleteIssue(ctx context.Context, owner string, repo string, number int) error {
+	xReq := xhttpclient.NewDelete().
+		WithContext(ctx).
+		PathTemplate("/repos/{owner}/{repo}/issues/{number}").
+		PathParam("owner", owner).
+		PathParam("repo", repo).
+		PathParam("number", fmt.Sprint(number))
+
+	if _, _, err := c.xc.Do(xhttpclient.Discard, nil, xReq); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *apiClient) UpdateRepo(ctx context.Context, owner string, repo string, update RepoUpdate) (*Repo, error) {
+	xReq := xhttpclient.NewPatch().
+		WithContext(ctx).
+		Bind(update).
+		PathTemplate("/repos/{owner}/{repo}").
+		PathParam("owner", owner).
+		PathParam("repo", repo)
+
+	out := new(Repo)
+	if _, _, err := c.xc.Do(out, nil, xReq); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
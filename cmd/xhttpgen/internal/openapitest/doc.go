@@ -0,0 +1,4 @@
+// Package openapitest is generated by xhttpgen openapi, petstore_xhttp.go is compared with the generated source by the tests.
+package openapitest
+
+//go:generate go run github.com/electricbubble/xhttpclient/cmd/xhttpgen openapi petstore.json
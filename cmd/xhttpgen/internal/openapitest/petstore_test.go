@@ -0,0 +1,157 @@
+package openapitest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+func TestClient(t *testing.T) {
+	var last *http.Request
+	var lastBody string
+	cli := NewClient(xhttpclient.NewClient().
+		BaseURL("https://petstore.example.com/v1").
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			last = r
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+				if err := r.ParseMultipartForm(1 << 20); err != nil {
+					t.Error(err)
+				}
+			} else {
+				body, _ := io.ReadAll(r.Body)
+				lastBody = string(body)
+			}
+
+			switch r.Method + " " + r.URL.Path {
+			case "GET /v1/pets":
+				if r.URL.Query().Get("limit") == "0" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					io.WriteString(w, `{"code":503,"message":"down"}`)
+					return
+				}
+				io.WriteString(w, `[{"id":1,"name":"a"},{"id":2,"name":"b","tag":"t"}]`)
+			case "POST /v1/pets":
+				if strings.Contains(lastBody, `"review"`) {
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				io.WriteString(w, `{"fields":{"name":"required"}}`)
+			case "GET /v1/pets/1":
+				io.WriteString(w, `{"id":1,"name":"a","status":"sold"}`)
+			case "GET /v1/pets/2":
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"code":404,"message":"not found"}`)
+			case "GET /v1/pets/3":
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"title":"Not Found","status":404,"code":404,"message":"gone"}`)
+			case "DELETE /v1/pets/1":
+				w.WriteHeader(http.StatusBadGateway)
+				io.WriteString(w, "<html>")
+			case "PUT /v1/pets/1/name":
+				w.WriteHeader(http.StatusConflict)
+				io.WriteString(w, "taken")
+			case "POST /v1/pets/1/photos":
+				io.WriteString(w, "https://cdn.example.com/1.png")
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			}
+		})))
+	ctx := context.Background()
+
+	limit := int32(10)
+	pets, err := cli.ListPets(ctx, ListPetsParams{Limit: &limit, Tags: []string{"x", "y"}})
+	if err != nil || len(pets) != 2 || pets[1].Tag == nil || *pets[1].Tag != "t" {
+		t.Fatalf("pets = %+v, err = %v", pets, err)
+	}
+	if got := last.URL.RawQuery; got != "limit=10&tags=x%2Cy" {
+		t.Fatalf("query = %s", got)
+	}
+	limit = 0
+	_, err = cli.ListPets(ctx, ListPetsParams{Limit: &limit})
+	var defaultErr *ListPetsDefaultError
+	if !errors.As(err, &defaultErr) || defaultErr.StatusCode != http.StatusServiceUnavailable || defaultErr.Body.Message != "down" {
+		t.Fatalf("err = %#v", err)
+	}
+
+	_, err = cli.CreatePet(ctx, NewPet{Name: "c", Status: ptr(StatusAvailable)})
+	var invalid *CreatePet422Error
+	if !errors.As(err, &invalid) || invalid.Body.Fields["name"] != "required" {
+		t.Fatalf("err = %#v", err)
+	}
+	if lastBody != `{"name":"c","status":"available"}`+"\n" || last.Header.Get("Content-Type") != xhttpclient.ContentTypeValueJSON {
+		t.Fatalf("body = %q, content type = %s", lastBody, last.Header.Get("Content-Type"))
+	}
+
+	if pet, err := cli.CreatePet(ctx, NewPet{Name: "review"}); err != nil || pet != nil {
+		t.Fatalf("pet = %+v, err = %v", pet, err)
+	}
+
+	pet, err := cli.GetPet(ctx, GetPetParams{PetID: 1})
+	if err != nil || pet.ID != 1 || pet.Name != "a" || *pet.Status != StatusSold {
+		t.Fatalf("pet = %+v, err = %v", pet, err)
+	}
+	_, err = cli.GetPet(ctx, GetPetParams{PetID: 2})
+	var notFound *GetPet404Error
+	if !errors.As(err, &notFound) || notFound.Body.Code != 404 {
+		t.Fatalf("err = %#v", err)
+	}
+
+	// problem details
+	_, err = cli.GetPet(ctx, GetPetParams{PetID: 3})
+	if !errors.As(err, &notFound) || notFound.Body.Code != 404 || notFound.Body.Message != "gone" {
+		t.Fatalf("err = %#v", err)
+	}
+
+	err = cli.DeletePet(ctx, DeletePetParams{PetID: 1})
+	var unexpected *UnexpectedStatusError
+	if !errors.As(err, &unexpected) || unexpected.Operation != "DeletePet" || string(unexpected.Body) != "<html>" {
+		t.Fatalf("err = %#v", err)
+	}
+
+	_, err = cli.RenamePet(ctx, RenamePetParams{PetID: 1}, PetName{Name: "d", Aliases: []string{"e", "f"}})
+	var rejected *RenamePet4XXError
+	if !errors.As(err, &rejected) || rejected.StatusCode != http.StatusConflict || string(rejected.Body) != "taken" {
+		t.Fatalf("err = %#v", err)
+	}
+	if lastBody != "aliases=e&aliases=f&name=d" || last.Header.Get("Content-Type") != xhttpclient.ContentTypeValueFormUrlencoded {
+		t.Fatalf("body = %q, content type = %s", lastBody, last.Header.Get("Content-Type"))
+	}
+
+	taken := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	url, err := cli.PostPetsPetIDPhotos(ctx, PostPetsPetIDPhotosParams{PetID: 1, XRequestID: "r1"}, PostPetsPetIDPhotosBody{
+		File:   strings.NewReader("png"),
+		Taken:  &taken,
+		Labels: map[string]string{"k": "v"},
+	})
+	if err != nil || string(url) != "https://cdn.example.com/1.png" {
+		t.Fatalf("url = %s, err = %v", url, err)
+	}
+	form := last.MultipartForm
+	if last.Header.Get("X-Request-ID") != "r1" || form.Value["taken"][0] != "2024-01-02T03:04:05Z" || form.Value["labels"][0] != `{"k":"v"}` {
+		t.Fatalf("header = %v, form = %v", last.Header, form.Value)
+	}
+	if fh := form.File["file"]; len(fh) != 1 || fh[0].Filename != "file" || fh[0].Size != 3 {
+		t.Fatalf("files = %+v", form.File)
+	}
+	if _, ok := form.Value["caption"]; ok {
+		t.Fatal("nil caption is sent")
+	}
+
+	b, _ := json.Marshal(Pet{NewPet: NewPet{Name: "a"}, ID: 1})
+	if string(b) != `{"name":"a","id":1}` {
+		t.Fatalf("pet = %s", b)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,379 @@
+// Code generated by xhttpgen openapi petstore.json; DO NOT EDIT.
+
+package openapitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+// Client is the client of the operations.
+type Client struct {
+	xc *xhttpclient.XClient
+}
+
+// NewClient sends the requests with xc, which holds the BaseURL.
+func NewClient(xc *xhttpclient.XClient) *Client {
+	return &Client{xc: xc}
+}
+
+// UnexpectedStatusError is an unsuccessful response that is not documented by the operation.
+type UnexpectedStatusError struct {
+	Operation  string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.Operation, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+type NewPet struct {
+	Born   *time.Time `json:"born,omitempty"`
+	Name   string     `json:"name"`
+	Status *Status    `json:"status,omitempty"`
+	// The tag of the pet.
+	Tag *string `json:"tag,omitempty"`
+}
+
+// Pet is a pet in the store.
+type Pet struct {
+	NewPet
+	ID int64 `json:"id"`
+}
+
+type PetName struct {
+	Aliases []string `json:"aliases,omitempty"`
+	Name    string   `json:"name"`
+	Status  *Status  `json:"status,omitempty"`
+}
+
+type Pets []Pet
+
+type Status string
+
+const (
+	StatusAvailable Status = "available"
+	StatusSold      Status = "sold"
+)
+
+// ListPetsParams is the parameters of ListPets.
+type ListPetsParams struct {
+	// The maximum number of pets.
+	Limit      *int32   `query:"limit,omitempty"`
+	Tags       []string `query:"tags,omitempty,comma"`
+	XRequestID *string  `header:"X-Request-ID,omitempty"`
+}
+
+// ListPets sends GET /pets.
+//
+// List the pets.
+func (c *Client) ListPets(ctx context.Context, params ListPetsParams) (Pets, error) {
+	xReq := xhttpclient.NewGet().
+		WithContext(ctx).
+		PathTemplate("/pets").
+		Bind(params)
+
+	var out Pets
+	resp, respBody, err := c.xc.Do(&out, xhttpclient.Discard, xReq)
+	if resp != nil && !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, decodeListPetsError(resp, respBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListPetsDefaultError is the default response of ListPets.
+//
+// An error.
+type ListPetsDefaultError struct {
+	StatusCode int
+	Body       Error
+}
+
+func (e *ListPetsDefaultError) Error() string {
+	return fmt.Sprintf("ListPets: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func decodeListPetsError(resp *http.Response, respBody []byte) error {
+	switch code := resp.StatusCode; {
+	default:
+		e := &ListPetsDefaultError{StatusCode: code}
+		if err := json.Unmarshal(respBody, &e.Body); err != nil {
+			return fmt.Errorf("ListPets: decode %d response: %w", code, err)
+		}
+		return e
+	}
+}
+
+// CreatePet sends POST /pets.
+func (c *Client) CreatePet(ctx context.Context, body NewPet) (*Pet, error) {
+	xReq := xhttpclient.NewPost().
+		WithContext(ctx).
+		PathTemplate("/pets").
+		Body(body).
+		OnStatus(202, xhttpclient.Discard)
+
+	out := new(Pet)
+	resp, respBody, err := c.xc.DoOnceWithBodyCodec(xhttpclient.BodyCodecJSON, out, xhttpclient.Discard, xReq)
+	if resp != nil && !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, decodeCreatePetError(resp, respBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case 202:
+		return nil, nil
+	}
+	return out, nil
+}
+
+type CreatePet422Body struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// CreatePet422Error is the 422 response of CreatePet.
+//
+// The pet is invalid.
+type CreatePet422Error struct {
+	StatusCode int
+	Body       CreatePet422Body
+}
+
+func (e *CreatePet422Error) Error() string {
+	return fmt.Sprintf("CreatePet: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func decodeCreatePetError(resp *http.Response, respBody []byte) error {
+	switch code := resp.StatusCode; {
+	case code == 422:
+		e := &CreatePet422Error{StatusCode: code}
+		if err := json.Unmarshal(respBody, &e.Body); err != nil {
+			return fmt.Errorf("CreatePet: decode %d response: %w", code, err)
+		}
+		return e
+	}
+	return &UnexpectedStatusError{Operation: "CreatePet", StatusCode: resp.StatusCode, Body: respBody}
+}
+
+// GetPetParams is the parameters of GetPet.
+type GetPetParams struct {
+	PetID int64 `path:"petId"`
+}
+
+// GetPet sends GET /pets/{petId}.
+func (c *Client) GetPet(ctx context.Context, params GetPetParams) (*Pet, error) {
+	xReq := xhttpclient.NewGet().
+		WithContext(ctx).
+		PathTemplate("/pets/{petId}").
+		Bind(params)
+
+	out := new(Pet)
+	resp, respBody, err := c.xc.Do(out, xhttpclient.Discard, xReq)
+	if resp != nil && !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, decodeGetPetError(resp, respBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetPet404Error is the 404 response of GetPet.
+//
+// An error.
+type GetPet404Error struct {
+	StatusCode int
+	Body       Error
+}
+
+func (e *GetPet404Error) Error() string {
+	return fmt.Sprintf("GetPet: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func decodeGetPetError(resp *http.Response, respBody []byte) error {
+	switch code := resp.StatusCode; {
+	case code == 404:
+		e := &GetPet404Error{StatusCode: code}
+		if err := json.Unmarshal(respBody, &e.Body); err != nil {
+			return fmt.Errorf("GetPet: decode %d response: %w", code, err)
+		}
+		return e
+	}
+	return &UnexpectedStatusError{Operation: "GetPet", StatusCode: resp.StatusCode, Body: respBody}
+}
+
+// DeletePetParams is the parameters of DeletePet.
+type DeletePetParams struct {
+	PetID int64 `path:"petId"`
+}
+
+// DeletePet sends DELETE /pets/{petId}.
+//
+// Deprecated: the operation is deprecated.
+func (c *Client) DeletePet(ctx context.Context, params DeletePetParams) error {
+	xReq := xhttpclient.NewDelete().
+		WithContext(ctx).
+		PathTemplate("/pets/{petId}").
+		Bind(params)
+
+	resp, respBody, err := c.xc.Do(xhttpclient.Discard, xhttpclient.Discard, xReq)
+	if err != nil {
+		return err
+	}
+	if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return &UnexpectedStatusError{Operation: "DeletePet", StatusCode: resp.StatusCode, Body: respBody}
+	}
+	return nil
+}
+
+// RenamePetParams is the parameters of RenamePet.
+type RenamePetParams struct {
+	PetID int64 `path:"petId"`
+}
+
+// encodeForm encodes v as application/x-www-form-urlencoded, the complex values as JSON.
+func (v PetName) encodeForm() (url.Values, error) {
+	form := make(url.Values)
+	for _, e := range v.Aliases {
+		form.Add("aliases", e)
+	}
+	form.Add("name", v.Name)
+	if v.Status != nil {
+		form.Add("status", string(*v.Status))
+	}
+	return form, nil
+}
+
+// RenamePet sends PUT /pets/{petId}/name.
+func (c *Client) RenamePet(ctx context.Context, params RenamePetParams, body PetName) (*Pet, error) {
+	encoded, err := body.encodeForm()
+	if err != nil {
+		return nil, err
+	}
+	xReq := xhttpclient.NewPut().
+		WithContext(ctx).
+		PathTemplate("/pets/{petId}/name").
+		Bind(params).
+		Body(encoded)
+
+	out := new(Pet)
+	resp, respBody, err := c.xc.DoOnceWithBodyCodec(xhttpclient.BodyCodecFormUrlencodedAndJSON, out, xhttpclient.Discard, xReq)
+	if resp != nil && !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, decodeRenamePetError(resp, respBody)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RenamePet4XXError is the 4XX response of RenamePet.
+//
+// The name is rejected.
+type RenamePet4XXError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *RenamePet4XXError) Error() string {
+	return fmt.Sprintf("RenamePet: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func decodeRenamePetError(resp *http.Response, respBody []byte) error {
+	switch code := resp.StatusCode; {
+	case code/100 == 4:
+		return &RenamePet4XXError{StatusCode: code, Body: respBody}
+	}
+	return &UnexpectedStatusError{Operation: "RenamePet", StatusCode: resp.StatusCode, Body: respBody}
+}
+
+// PostPetsPetIDPhotosParams is the parameters of PostPetsPetIDPhotos.
+type PostPetsPetIDPhotosParams struct {
+	PetID      int64  `path:"petId"`
+	XRequestID string `header:"X-Request-ID"`
+}
+
+type PostPetsPetIDPhotosBody struct {
+	Caption *string           `json:"caption,omitempty"`
+	File    io.Reader         `json:"file"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Taken   *time.Time        `json:"taken,omitempty"`
+}
+
+// encodeMultipart encodes v as multipart/form-data, the complex values as JSON.
+func (v PostPetsPetIDPhotosBody) encodeMultipart() (*xhttpclient.XMultipartWriter, error) {
+	xmw := xhttpclient.NewMultipartWriter()
+	var (
+		b   []byte
+		err error
+	)
+	if v.Caption != nil {
+		xmw.WriteWithFieldValue("caption", *v.Caption)
+	}
+	if v.File != nil {
+		writeMultipartFile(xmw, "file", v.File)
+	}
+	if v.Labels != nil {
+		if b, err = json.Marshal(v.Labels); err != nil {
+			return nil, fmt.Errorf("labels: %w", err)
+		}
+		xmw.WriteWithFieldValue("labels", string(b))
+	}
+	if v.Taken != nil {
+		xmw.WriteWithFieldValue("taken", v.Taken.Format(time.RFC3339))
+	}
+	return xmw, nil
+}
+
+// PostPetsPetIDPhotos sends POST /pets/{petId}/photos.
+func (c *Client) PostPetsPetIDPhotos(ctx context.Context, params PostPetsPetIDPhotosParams, body PostPetsPetIDPhotosBody) ([]byte, error) {
+	encoded, err := body.encodeMultipart()
+	if err != nil {
+		return nil, err
+	}
+	xReq := xhttpclient.NewPost().
+		WithContext(ctx).
+		PathTemplate("/pets/{petId}/photos").
+		Bind(params).
+		Body(encoded)
+
+	resp, respBody, err := c.xc.DoOnceWithBodyCodec(xhttpclient.BodyCodecMultipart, xhttpclient.Discard, xhttpclient.Discard, xReq)
+	if err != nil {
+		return nil, err
+	}
+	if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {
+		return nil, &UnexpectedStatusError{Operation: "PostPetsPetIDPhotos", StatusCode: resp.StatusCode, Body: respBody}
+	}
+	return respBody, nil
+}
+
+// writeMultipartFile writes r as the file of the field, named after r.Name() if implemented, e.g. *os.File.
+func writeMultipartFile(xmw *xhttpclient.XMultipartWriter, field string, r io.Reader) {
+	filename := field
+	if f, ok := r.(interface{ Name() string }); ok {
+		filename = filepath.Base(f.Name())
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": filename}))
+	header.Set("Content-Type", "application/octet-stream")
+	xmw.WriteWithHeader(header, r)
+}
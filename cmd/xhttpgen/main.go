@@ -0,0 +1,84 @@
+// Command xhttpgen generates API clients backed by xhttpclient.
+//
+// Usage:
+//
+//	xhttpgen iface -type Name [-impl name] [-output file] [dir]
+//	xhttpgen openapi [-package name] [-client Client] [-output file] spec.json
+//
+// The iface command implements a Go interface annotated with xhttp comments, see iface.go.
+// The openapi command generates the types and the client of an OpenAPI 3 spec, see openapi.go.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "xhttpgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stderr io.Writer) error {
+	if len(args) == 0 {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "iface":
+		return runIface(args[1:], stderr)
+	case "openapi":
+		return runOpenAPI(args[1:], stderr)
+	case "-h", "-help", "--help", "help":
+		usage(stderr)
+		return nil
+	}
+	usage(stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, `Usage:
+
+	xhttpgen iface -type Name [-impl name] [-output file] [dir]
+		implements the annotated Go interface Name of the package in dir (default ".")
+	xhttpgen openapi [-package name] [-client Client] [-output file] spec.json
+		generates the types and the client of the OpenAPI 3 spec, JSON only
+`)
+}
+
+// writeImports writes the import declaration of imports (name -> path), the standard library first.
+func writeImports(buf *bytes.Buffer, imports map[string]string) {
+	var std, others []string
+	aliases := make(map[string]string, len(imports))
+	for name, path := range imports {
+		if first, _, _ := strings.Cut(path, "/"); strings.Contains(first, ".") {
+			others = append(others, path)
+		} else {
+			std = append(std, path)
+		}
+		if pathPackageName(path) != name {
+			aliases[path] = name
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(others)
+
+	fmt.Fprintf(buf, "import (\n")
+	for _, path := range std {
+		fmt.Fprintf(buf, "\t%s %q\n", aliases[path], path)
+	}
+	fmt.Fprintf(buf, "\n")
+	for _, path := range others {
+		fmt.Fprintf(buf, "\t%s %q\n", aliases[path], path)
+	}
+	fmt.Fprintf(buf, ")\n\n")
+}
@@ -0,0 +1,1166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The openapi command generates the types of components.schemas and a client with a method per operation:
+//
+//	func (c *Client) GetPet(ctx context.Context, params GetPetParams) (*Pet, error)
+//	func (c *Client) CreatePet(ctx context.Context, body NewPet) (*Pet, error)
+//
+//   - the path, query and header parameters are the fields of <Operation>Params, set with Bind
+//   - the request body is encoded with the BodyCodec of its media type, in order of preference:
+//     application/json (or +json), application/x-www-form-urlencoded and multipart/form-data,
+//     the complex values of a form are encoded as JSON, a binary string of a multipart form is an io.Reader
+//   - the JSON content of the first 2xx response is decoded into the result, any other content is returned as []byte
+//   - every other documented response is returned as *<Operation><Status>Error with the content decoded into Body,
+//     an undocumented one as *UnexpectedStatusError
+//
+// Only JSON specs are supported, the standard library has no YAML decoder.
+
+type oaSpec struct {
+	OpenAPI    string                 `json:"openapi"`
+	Paths      map[string]*oaPathItem `json:"paths"`
+	Components struct {
+		Schemas       map[string]*oaSchema      `json:"schemas"`
+		Parameters    map[string]*oaParameter   `json:"parameters"`
+		RequestBodies map[string]*oaRequestBody `json:"requestBodies"`
+		Responses     map[string]*oaResponse    `json:"responses"`
+	} `json:"components"`
+}
+
+type oaPathItem struct {
+	Parameters []*oaParameter `json:"parameters"`
+	Get        *oaOperation   `json:"get"`
+	Put        *oaOperation   `json:"put"`
+	Post       *oaOperation   `json:"post"`
+	Delete     *oaOperation   `json:"delete"`
+	Options    *oaOperation   `json:"options"`
+	Head       *oaOperation   `json:"head"`
+	Patch      *oaOperation   `json:"patch"`
+	Trace      *oaOperation   `json:"trace"`
+}
+
+func (item *oaPathItem) operations() []*oaOperation {
+	return []*oaOperation{item.Get, item.Put, item.Post, item.Delete, item.Options, item.Head, item.Patch, item.Trace}
+}
+
+var _oaMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+type oaOperation struct {
+	OperationID string                 `json:"operationId"`
+	Summary     string                 `json:"summary"`
+	Description string                 `json:"description"`
+	Deprecated  bool                   `json:"deprecated"`
+	Parameters  []*oaParameter         `json:"parameters"`
+	RequestBody *oaRequestBody         `json:"requestBody"`
+	Responses   map[string]*oaResponse `json:"responses"`
+}
+
+type oaParameter struct {
+	Ref         string    `json:"$ref"`
+	Name        string    `json:"name"`
+	In          string    `json:"in"`
+	Description string    `json:"description"`
+	Required    bool      `json:"required"`
+	Explode     *bool     `json:"explode"`
+	Schema      *oaSchema `json:"schema"`
+}
+
+type oaRequestBody struct {
+	Ref      string                  `json:"$ref"`
+	Required bool                    `json:"required"`
+	Content  map[string]*oaMediaType `json:"content"`
+}
+
+type oaResponse struct {
+	Ref         string                  `json:"$ref"`
+	Description string                  `json:"description"`
+	Content     map[string]*oaMediaType `json:"content"`
+}
+
+type oaMediaType struct {
+	Schema *oaSchema `json:"schema"`
+}
+
+type oaSchema struct {
+	Ref                  string               `json:"$ref"`
+	Type                 oaTypes              `json:"type"`
+	Format               string               `json:"format"`
+	Description          string               `json:"description"`
+	Nullable             bool                 `json:"nullable"`
+	Enum                 []any                `json:"enum"`
+	Items                *oaSchema            `json:"items"`
+	Properties           map[string]*oaSchema `json:"properties"`
+	Required             []string             `json:"required"`
+	AdditionalProperties json.RawMessage      `json:"additionalProperties"`
+	AllOf                []*oaSchema          `json:"allOf"`
+	OneOf                []*oaSchema          `json:"oneOf"`
+	AnyOf                []*oaSchema          `json:"anyOf"`
+}
+
+// oaTypes is the type of a schema, a string or an array with "null" since OpenAPI 3.1.
+type oaTypes []string
+
+func (t *oaTypes) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = oaTypes{s}
+		return nil
+	}
+	return json.Unmarshal(b, (*[]string)(t))
+}
+
+// typ returns the type other than "null".
+func (s *oaSchema) typ() string {
+	for _, t := range s.Type {
+		if t != "null" {
+			return t
+		}
+	}
+	if len(s.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+func (s *oaSchema) nullable() bool {
+	for _, t := range s.Type {
+		if t == "null" {
+			return true
+		}
+	}
+	return s.Nullable
+}
+
+// additional returns the schema of additionalProperties, nil if it is absent or false.
+func (s *oaSchema) additional() (*oaSchema, error) {
+	switch raw := strings.TrimSpace(string(s.AdditionalProperties)); raw {
+	case "", "false":
+		return nil, nil
+	case "true":
+		return &oaSchema{}, nil
+	}
+	ap := new(oaSchema)
+	if err := json.Unmarshal(s.AdditionalProperties, ap); err != nil {
+		return nil, fmt.Errorf("additionalProperties: %w", err)
+	}
+	return ap, nil
+}
+
+func runOpenAPI(args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pkgName := flags.String("package", "", "package name, default is the name of the output directory")
+	client := flags.String("client", "Client", "client type name, the constructor is New<client>")
+	output := flags.String("output", "", "output file, default is <spec>_xhttp.go next to the spec")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("exactly one spec file is required")
+	}
+
+	specFile := flags.Arg(0)
+	if *output == "" {
+		*output = filepath.Join(filepath.Dir(specFile), strings.TrimSuffix(filepath.Base(specFile), filepath.Ext(specFile))+"_xhttp.go")
+	}
+	if *pkgName == "" {
+		dir, err := filepath.Abs(filepath.Dir(*output))
+		if err != nil {
+			return err
+		}
+		*pkgName = pathPackageName(filepath.Base(dir))
+	}
+
+	data, err := os.ReadFile(specFile)
+	if err != nil {
+		return err
+	}
+	src, err := generateOpenAPI(data, filepath.Base(specFile), *pkgName, *client)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// oaField is a generated struct field.
+type oaField struct {
+	prop   string
+	name   string
+	typ    string // with the pointer of an optional value
+	schema *oaSchema
+}
+
+type openapiGenerator struct {
+	spec     *oaSpec
+	client   string
+	imports  map[string]string
+	decls    bytes.Buffer
+	declared map[string]bool
+	structs  map[string][]oaField // the fields of the declared structs, including the embedded ones
+	encoders map[string]bool      // the declared encodeForm and encodeMultipart methods
+	files    bool                 // writeMultipartFile is used
+}
+
+func generateOpenAPI(data []byte, source, pkgName, client string) ([]byte, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, errors.New("only JSON specs are supported")
+	}
+	spec := new(oaSpec)
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", spec.OpenAPI)
+	}
+
+	g := &openapiGenerator{
+		spec:   spec,
+		client: client,
+		imports: map[string]string{
+			"context":     "context",
+			"fmt":         "fmt",
+			"http":        "net/http",
+			"xhttpclient": "github.com/electricbubble/xhttpclient",
+		},
+		declared: map[string]bool{client: true, "UnexpectedStatusError": true},
+		structs:  make(map[string][]oaField),
+		encoders: make(map[string]bool),
+	}
+
+	for _, name := range sortedKeys(spec.Components.Schemas) {
+		s := spec.Components.Schemas[name]
+		if err := g.declare(goName(name), s); err != nil {
+			return nil, fmt.Errorf("components.schemas.%s: %w", name, err)
+		}
+	}
+
+	methods := make(map[string]string)
+	for _, path := range sortedKeys(spec.Paths) {
+		item := spec.Paths[path]
+		for i, op := range item.operations() {
+			if op == nil {
+				continue
+			}
+			method := _oaMethods[i]
+			name := goName(op.OperationID)
+			if op.OperationID == "" {
+				name = goName(strings.ToLower(method) + " " + path)
+			}
+			if prev, ok := methods[name]; ok {
+				return nil, fmt.Errorf("%s %s: operation %s is declared by %s", method, path, name, prev)
+			}
+			methods[name] = method + " " + path
+			if err := g.operation(name, method, path, item, op); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by xhttpgen openapi %s; DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	writeImports(&buf, g.imports)
+
+	fmt.Fprintf(&buf, "// %s is the client of the operations.\n", client)
+	fmt.Fprintf(&buf, "type %s struct {\n\txc *xhttpclient.XClient\n}\n\n", client)
+	fmt.Fprintf(&buf, "// New%s sends the requests with xc, which holds the BaseURL.\n", client)
+	fmt.Fprintf(&buf, "func New%s(xc *xhttpclient.XClient) *%s {\n\treturn &%s{xc: xc}\n}\n\n", client, client, client)
+	buf.WriteString(`// UnexpectedStatusError is an unsuccessful response that is not documented by the operation.
+type UnexpectedStatusError struct {
+	Operation  string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *UnexpectedStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d %s", e.Operation, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+`)
+	buf.Write(g.decls.Bytes())
+	if g.files {
+		buf.WriteString(`// writeMultipartFile writes r as the file of the field, named after r.Name() if implemented, e.g. *os.File.
+func writeMultipartFile(xmw *xhttpclient.XMultipartWriter, field string, r io.Reader) {
+	filename := field
+	if f, ok := r.(interface{ Name() string }); ok {
+		filename = filepath.Base(f.Name())
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": field, "filename": filename}))
+	header.Set("Content-Type", "application/octet-stream")
+	xmw.WriteWithHeader(header, r)
+}
+`)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w\n%s", err, buf.Bytes())
+	}
+	return src, nil
+}
+
+// declare declares the type name of s, the inline types of the fields are declared before.
+func (g *openapiGenerator) declare(name string, s *oaSchema) error {
+	if g.declared[name] {
+		return fmt.Errorf("duplicate type %s", name)
+	}
+	g.declared[name] = true
+
+	var w bytes.Buffer
+	writeComment(&w, s.Description)
+	switch {
+	case s.Ref == "" && (len(s.Properties) > 0 || len(s.AllOf) > 1):
+		fields, err := g.structFields(&w, name, s)
+		if err != nil {
+			return err
+		}
+		g.structs[name] = fields
+	case s.Ref == "" && s.typ() == "string" && len(s.Enum) > 0 && s.Format == "":
+		fmt.Fprintf(&w, "type %s string\n\nconst (\n", name)
+		for _, e := range s.Enum {
+			v, ok := e.(string)
+			if !ok {
+				return fmt.Errorf("enum value %v is not a string", e)
+			}
+			fmt.Fprintf(&w, "%s%s %s = %q\n", name, goName(v), name, v)
+		}
+		fmt.Fprintf(&w, ")\n\n")
+	default:
+		typ, err := g.typeExpr(s, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&w, "type %s %s\n\n", name, typ)
+	}
+	g.decls.Write(w.Bytes())
+	return nil
+}
+
+// structFields writes the struct of s, the allOf references are embedded.
+func (g *openapiGenerator) structFields(w *bytes.Buffer, name string, s *oaSchema) ([]oaField, error) {
+	var (
+		embedded []string
+		fields   []oaField
+		props    = make(map[string]*oaSchema)
+		required = make(map[string]bool)
+	)
+	for _, sub := range append(s.AllOf, s) {
+		if sub.Ref != "" {
+			ref, err := g.refName(sub.Ref, "schemas")
+			if err != nil {
+				return nil, err
+			}
+			embedded = append(embedded, ref)
+			continue
+		}
+		if sub != s && len(sub.AllOf) > 0 {
+			return nil, errors.New("nested allOf is not supported")
+		}
+		for prop, ps := range sub.Properties {
+			props[prop] = ps
+		}
+		for _, r := range sub.Required {
+			required[r] = true
+		}
+	}
+
+	var body bytes.Buffer
+	for _, e := range embedded {
+		fmt.Fprintf(&body, "%s\n", e)
+		fields = append(fields, g.structs[e]...)
+	}
+	used := make(map[string]bool)
+	for _, prop := range sortedKeys(props) {
+		ps := props[prop]
+		field := uniqueName(goName(prop), used)
+		typ, err := g.fieldType(ps, name+field, required[prop])
+		if err != nil {
+			return nil, fmt.Errorf("property '%s': %w", prop, err)
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+		writeComment(&body, ps.Description)
+		fmt.Fprintf(&body, "%s %s `json:%s`\n", field, typ, strconv.Quote(tag))
+		fields = append(fields, oaField{prop: prop, name: field, typ: typ, schema: ps})
+	}
+	fmt.Fprintf(w, "type %s struct {\n%s}\n\n", name, body.Bytes())
+	return fields, nil
+}
+
+// fieldType is the type of s, with a pointer if the value is optional or nullable.
+func (g *openapiGenerator) fieldType(s *oaSchema, hint string, required bool) (string, error) {
+	typ, err := g.typeExpr(s, hint)
+	if err != nil {
+		return "", err
+	}
+	if (!required || s.nullable()) && !g.nilable(typ, s) {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// typeExpr returns the Go type of s, an inline object is declared as hint.
+func (g *openapiGenerator) typeExpr(s *oaSchema, hint string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		return g.refName(s.Ref, "schemas")
+	}
+	if len(s.AllOf) == 1 && len(s.Properties) == 0 {
+		return g.typeExpr(s.AllOf[0], hint)
+	}
+	if len(s.Properties) > 0 || len(s.AllOf) > 1 {
+		if err := g.declare(hint, &oaSchema{Properties: s.Properties, Required: s.Required, AllOf: s.AllOf}); err != nil {
+			return "", err
+		}
+		return hint, nil
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		g.imports["json"] = "encoding/json"
+		return "json.RawMessage", nil
+	}
+
+	switch s.typ() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = "time"
+			return "time.Time", nil
+		case "binary":
+			g.imports["io"] = "io"
+			return "io.Reader", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+	case "integer":
+		if s.Format == "int32" || s.Format == "int64" {
+			return s.Format, nil
+		}
+		return "int", nil
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		elem, err := g.typeExpr(s.Items, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object", "":
+		ap, err := s.additional()
+		if err != nil {
+			return "", err
+		}
+		if ap != nil {
+			elem, err := g.typeExpr(ap, hint+"Value")
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + elem, nil
+		}
+		if s.typ() == "object" {
+			return "map[string]any", nil
+		}
+		return "any", nil
+	}
+	return "", fmt.Errorf("unsupported type %q", s.typ())
+}
+
+// nilable reports whether the value of typ can be nil without a pointer.
+func (g *openapiGenerator) nilable(typ string, s *oaSchema) bool {
+	if strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") || strings.HasPrefix(typ, "*") {
+		return true
+	}
+	switch typ {
+	case "any", "json.RawMessage", "io.Reader":
+		return true
+	}
+	r := g.resolveSchema(s)
+	if r == s {
+		return false
+	}
+	if len(r.Properties) > 0 || len(r.AllOf) > 0 {
+		return false
+	}
+	switch r.typ() {
+	case "array", "object", "":
+		return true
+	}
+	return len(r.OneOf) > 0 || len(r.AnyOf) > 0
+}
+
+func (g *openapiGenerator) refName(ref, kind string) (string, error) {
+	name, ok := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if ok {
+		switch kind {
+		case "schemas":
+			_, ok = g.spec.Components.Schemas[name]
+		case "parameters":
+			_, ok = g.spec.Components.Parameters[name]
+		case "requestBodies":
+			_, ok = g.spec.Components.RequestBodies[name]
+		case "responses":
+			_, ok = g.spec.Components.Responses[name]
+		}
+	}
+	if !ok {
+		return "", fmt.Errorf("unresolved $ref %q", ref)
+	}
+	return goName(name), nil
+}
+
+// _maxRefHops bounds the chains of $ref, which may be cyclic.
+const _maxRefHops = 32
+
+// resolveSchema follows the references of s, a missing one is reported by typeExpr.
+func (g *openapiGenerator) resolveSchema(s *oaSchema) *oaSchema {
+	for i := 0; s != nil && s.Ref != "" && i < _maxRefHops; i++ {
+		next := g.spec.Components.Schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")]
+		if next == nil {
+			break
+		}
+		s = next
+	}
+	return s
+}
+
+func (g *openapiGenerator) parameter(p *oaParameter) (*oaParameter, error) {
+	for i := 0; p.Ref != ""; i++ {
+		if i == _maxRefHops {
+			return nil, fmt.Errorf("$ref %q: more than %d hops", p.Ref, _maxRefHops)
+		}
+		if _, err := g.refName(p.Ref, "parameters"); err != nil {
+			return nil, err
+		}
+		p = g.spec.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+	}
+	return p, nil
+}
+
+func (g *openapiGenerator) requestBody(rb *oaRequestBody) (*oaRequestBody, error) {
+	for i := 0; rb.Ref != ""; i++ {
+		if i == _maxRefHops {
+			return nil, fmt.Errorf("$ref %q: more than %d hops", rb.Ref, _maxRefHops)
+		}
+		if _, err := g.refName(rb.Ref, "requestBodies"); err != nil {
+			return nil, err
+		}
+		rb = g.spec.Components.RequestBodies[strings.TrimPrefix(rb.Ref, "#/components/requestBodies/")]
+	}
+	return rb, nil
+}
+
+func (g *openapiGenerator) response(r *oaResponse) (*oaResponse, error) {
+	for i := 0; r.Ref != ""; i++ {
+		if i == _maxRefHops {
+			return nil, fmt.Errorf("$ref %q: more than %d hops", r.Ref, _maxRefHops)
+		}
+		if _, err := g.refName(r.Ref, "responses"); err != nil {
+			return nil, err
+		}
+		r = g.spec.Components.Responses[strings.TrimPrefix(r.Ref, "#/components/responses/")]
+	}
+	return r, nil
+}
+
+// oaBody is the request body of an operation.
+type oaBody struct {
+	typ      string
+	codec    string // the BodyCodec variable
+	encoder  string // the encode method of a form
+	optional bool
+}
+
+// oaResult is the content of a response.
+type oaResult struct {
+	typ    string // empty without content
+	json   bool
+	schema *oaSchema
+}
+
+func (g *openapiGenerator) operation(name, method, path string, item *oaPathItem, op *oaOperation) error {
+	params, err := g.params(name, item, op)
+	if err != nil {
+		return err
+	}
+	var body *oaBody
+	if op.RequestBody != nil {
+		if body, err = g.body(name, op.RequestBody); err != nil {
+			return fmt.Errorf("requestBody: %w", err)
+		}
+	}
+
+	var (
+		codes   = sortedStatusCodes(op.Responses)
+		result  oaResult
+		success bool
+		errs    []string
+		// empty are the successful status codes without content, they are not decoded into the result.
+		empty []string
+	)
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			errs = append(errs, code)
+			continue
+		}
+		r, err := g.response(op.Responses[code])
+		if err != nil {
+			return fmt.Errorf("responses.%s: %w", code, err)
+		}
+		if len(r.Content) == 0 {
+			if _, err := strconv.Atoi(code); err == nil {
+				empty = append(empty, code)
+			}
+			continue
+		}
+		if success {
+			continue
+		}
+		if result, err = g.result(r, name+"Response"); err != nil {
+			return fmt.Errorf("responses.%s: %w", code, err)
+		}
+		success = result.typ != ""
+	}
+	if !result.json {
+		empty = nil
+	}
+
+	var w bytes.Buffer
+	fmt.Fprintf(&w, "// %s sends %s %s.\n", name, method, path)
+	for _, text := range []string{op.Summary, op.Description} {
+		if text != "" {
+			fmt.Fprintf(&w, "//\n")
+			writeComment(&w, text)
+		}
+	}
+	if op.Deprecated {
+		fmt.Fprintf(&w, "//\n// Deprecated: the operation is deprecated.\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	if params != "" {
+		args = append(args, "params "+params)
+	}
+	if body != nil {
+		args = append(args, "body "+body.typ)
+	}
+	zero, results := "", "error"
+	switch {
+	case result.typ == "":
+	case g.nilable(result.typ, result.schema):
+		zero, results = "nil, ", "("+result.typ+", error)"
+	default:
+		zero, results = "nil, ", "(*"+result.typ+", error)"
+	}
+	fmt.Fprintf(&w, "func (c *%s) %s(%s) %s {\n", g.client, name, strings.Join(args, ", "), results)
+
+	bodyV := "body"
+	if body != nil && body.encoder != "" {
+		bodyV = "encoded"
+		fmt.Fprintf(&w, "encoded, err := body.%s()\nif err != nil {\nreturn %serr\n}\n", body.encoder, zero)
+	}
+	fmt.Fprintf(&w, "xReq := xhttpclient.%s().\nWithContext(ctx).\nPathTemplate(%q)", _builderFuncs[method], path)
+	if params != "" {
+		fmt.Fprintf(&w, ".\nBind(params)")
+	}
+	if body != nil && !body.optional {
+		fmt.Fprintf(&w, ".\nBody(%s)", bodyV)
+	}
+	for _, code := range empty {
+		fmt.Fprintf(&w, ".\nOnStatus(%s, xhttpclient.Discard)", code)
+	}
+	fmt.Fprintf(&w, "\n")
+	if body != nil && body.optional {
+		fmt.Fprintf(&w, "if body != nil {\nxReq.Body(body)\n}\n")
+	}
+	fmt.Fprintf(&w, "\n")
+
+	successV, out := "xhttpclient.Discard", "nil"
+	switch {
+	case result.typ == "":
+	case !result.json:
+		out = "respBody"
+	case g.nilable(result.typ, result.schema):
+		fmt.Fprintf(&w, "var out %s\n", result.typ)
+		successV, out = "&out", "out"
+	default:
+		fmt.Fprintf(&w, "out := new(%s)\n", result.typ)
+		successV, out = "out", "out"
+	}
+	if body == nil {
+		fmt.Fprintf(&w, "resp, respBody, err := c.xc.Do(%s, xhttpclient.Discard, xReq)\n", successV)
+	} else {
+		fmt.Fprintf(&w, "resp, respBody, err := c.xc.DoOnceWithBodyCodec(xhttpclient.%s, %s, xhttpclient.Discard, xReq)\n", body.codec, successV)
+	}
+	if len(errs) == 0 {
+		fmt.Fprintf(&w, "if err != nil {\nreturn %serr\n}\n", zero)
+		fmt.Fprintf(&w, "if !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {\n")
+		fmt.Fprintf(&w, "return %s&UnexpectedStatusError{Operation: %q, StatusCode: resp.StatusCode, Body: respBody}\n}\n", zero, name)
+	} else {
+		// the typed errors take precedence over the err of the unsuccessful statuses, e.g. *xhttpclient.ProblemDetails
+		fmt.Fprintf(&w, "if resp != nil && !xhttpclient.ResponseIsSuccessfulGTE200LTE299(resp) {\n")
+		fmt.Fprintf(&w, "return %sdecode%sError(resp, respBody)\n}\n", zero, name)
+		fmt.Fprintf(&w, "if err != nil {\nreturn %serr\n}\n", zero)
+	}
+	if len(empty) != 0 {
+		fmt.Fprintf(&w, "switch resp.StatusCode {\ncase %s:\nreturn nil, nil\n}\n", strings.Join(empty, ", "))
+	}
+	if result.typ == "" {
+		fmt.Fprintf(&w, "return nil\n}\n\n")
+	} else {
+		fmt.Fprintf(&w, "return %s, nil\n}\n\n", out)
+	}
+	g.decls.Write(w.Bytes())
+
+	if len(errs) > 0 {
+		return g.errorTypes(name, op, errs)
+	}
+	return nil
+}
+
+// params declares <Operation>Params, it returns the name of the declared type, or empty without parameters.
+func (g *openapiGenerator) params(name string, item *oaPathItem, op *oaOperation) (string, error) {
+	var params []*oaParameter
+	index := make(map[string]int)
+	for _, p := range append(append([]*oaParameter(nil), item.Parameters...), op.Parameters...) {
+		p, err := g.parameter(p)
+		if err != nil {
+			return "", err
+		}
+		key := p.In + " " + p.Name
+		if i, ok := index[key]; ok {
+			params[i] = p
+			continue
+		}
+		index[key] = len(params)
+		params = append(params, p)
+	}
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	typeName := name + "Params"
+	if g.declared[typeName] {
+		return "", fmt.Errorf("duplicate type %s", typeName)
+	}
+	g.declared[typeName] = true
+
+	var body bytes.Buffer
+	used := make(map[string]bool)
+	for _, p := range params {
+		if p.Schema == nil {
+			return "", fmt.Errorf("parameter '%s': schema is required", p.Name)
+		}
+		field := goName(p.Name)
+		if used[field] {
+			field += goName(p.In)
+		}
+		field = uniqueName(field, used)
+
+		var tag string
+		switch p.In {
+		case "path":
+			if !p.Required {
+				return "", fmt.Errorf("path parameter '%s' must be required", p.Name)
+			}
+			tag = fmt.Sprintf("path:%q", p.Name)
+		case "query":
+			opts := ""
+			if !p.Required {
+				opts += ",omitempty"
+			}
+			if p.Explode != nil && !*p.Explode {
+				opts += ",comma"
+			}
+			tag = fmt.Sprintf("query:%q", p.Name+opts)
+		case "header":
+			opts := ""
+			if !p.Required {
+				opts += ",omitempty"
+			}
+			tag = fmt.Sprintf("header:%q", p.Name+opts)
+		default:
+			return "", fmt.Errorf("parameter '%s': %s parameters are not supported", p.Name, p.In)
+		}
+
+		typ, err := g.fieldType(p.Schema, typeName+field, p.Required)
+		if err != nil {
+			return "", fmt.Errorf("parameter '%s': %w", p.Name, err)
+		}
+		writeComment(&body, p.Description)
+		fmt.Fprintf(&body, "%s %s `%s`\n", field, typ, tag)
+	}
+
+	fmt.Fprintf(&g.decls, "// %s is the parameters of %s.\n", typeName, name)
+	fmt.Fprintf(&g.decls, "type %s struct {\n%s}\n\n", typeName, body.Bytes())
+	return typeName, nil
+}
+
+func (g *openapiGenerator) body(name string, rb *oaRequestBody) (*oaBody, error) {
+	rb, err := g.requestBody(rb)
+	if err != nil {
+		return nil, err
+	}
+
+	var mediaType string
+	var media *oaMediaType
+	for _, mt := range sortedKeys(rb.Content) {
+		if isJSONMediaType(mt) {
+			mediaType, media = "json", rb.Content[mt]
+			break
+		}
+		switch mt {
+		case "application/x-www-form-urlencoded":
+			mediaType, media = "form", rb.Content[mt]
+		case "multipart/form-data":
+			if mediaType == "" {
+				mediaType, media = "multipart", rb.Content[mt]
+			}
+		}
+	}
+	if media == nil {
+		return nil, fmt.Errorf("unsupported media types %v", sortedKeys(rb.Content))
+	}
+
+	if mediaType == "json" {
+		typ, err := g.fieldType(media.Schema, name+"Body", rb.Required)
+		if err != nil {
+			return nil, err
+		}
+		return &oaBody{typ: typ, codec: "BodyCodecJSON", optional: strings.HasPrefix(typ, "*") || !rb.Required}, nil
+	}
+
+	typ, err := g.typeExpr(media.Schema, name+"Body")
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := g.structs[typ]
+	if !ok {
+		return nil, fmt.Errorf("the schema of %s must be an object", mediaType)
+	}
+	b := &oaBody{typ: typ, codec: "BodyCodecFormUrlencodedAndJSON", encoder: "encodeForm"}
+	if mediaType == "multipart" {
+		b.codec, b.encoder = "BodyCodecMultipart", "encodeMultipart"
+	}
+	if !g.encoders[typ+"."+b.encoder] {
+		g.encoders[typ+"."+b.encoder] = true
+		if err := g.encoder(typ, b.encoder, fields); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// encoder declares the encodeForm or encodeMultipart method of the struct typ.
+func (g *openapiGenerator) encoder(typ, method string, fields []oaField) error {
+	multipart := method == "encodeMultipart"
+
+	var w bytes.Buffer
+	if multipart {
+		fmt.Fprintf(&w, "// %s encodes v as multipart/form-data, the complex values as JSON.\n", method)
+		fmt.Fprintf(&w, "func (v %s) %s() (*xhttpclient.XMultipartWriter, error) {\nxmw := xhttpclient.NewMultipartWriter()\n", typ, method)
+	} else {
+		g.imports["url"] = "net/url"
+		fmt.Fprintf(&w, "// %s encodes v as application/x-www-form-urlencoded, the complex values as JSON.\n", method)
+		fmt.Fprintf(&w, "func (v %s) %s() (url.Values, error) {\nform := make(url.Values)\n", typ, method)
+	}
+	var fw bytes.Buffer
+	marshal := false
+	add := func(prop, value string) string {
+		if multipart {
+			return fmt.Sprintf("xmw.WriteWithFieldValue(%q, %s)\n", prop, value)
+		}
+		return fmt.Sprintf("form.Add(%q, %s)\n", prop, value)
+	}
+
+	for _, f := range fields {
+		value := "v." + f.name
+		ptr := strings.HasPrefix(f.typ, "*")
+		if ptr {
+			value = "*" + value
+		}
+		nilCheck := ptr || g.nilable(f.typ, f.schema) && !strings.HasPrefix(f.typ, "[]")
+		if nilCheck {
+			fmt.Fprintf(&fw, "if v.%s != nil {\n", f.name)
+		}
+
+		s := g.resolveSchema(f.schema)
+		switch {
+		case f.typ == "io.Reader" || f.typ == "[]io.Reader":
+			if !multipart {
+				return fmt.Errorf("%s.%s: a binary string must be sent as multipart/form-data", typ, f.name)
+			}
+			g.files = true
+			g.imports["filepath"] = "path/filepath"
+			g.imports["mime"] = "mime"
+			g.imports["textproto"] = "net/textproto"
+			if f.typ == "io.Reader" {
+				fmt.Fprintf(&fw, "writeMultipartFile(xmw, %q, %s)\n", f.prop, value)
+			} else {
+				fmt.Fprintf(&fw, "for _, r := range %s {\nwriteMultipartFile(xmw, %q, r)\n}\n", value, f.prop)
+			}
+		case isScalarSchema(s):
+			fmt.Fprintf(&fw, "%s", add(f.prop, g.formatExpr(value, strings.TrimPrefix(f.typ, "*"), s)))
+		case s.typ() == "array" && isScalarSchema(g.resolveSchema(s.Items)):
+			elem := strings.TrimPrefix(strings.TrimPrefix(f.typ, "*"), "[]")
+			if elem == strings.TrimPrefix(f.typ, "*") {
+				elem = "" // a named slice type
+			}
+			fmt.Fprintf(&fw, "for _, e := range %s {\n%s}\n", value, add(f.prop, g.formatExpr("e", elem, g.resolveSchema(s.Items))))
+		default:
+			g.imports["json"] = "encoding/json"
+			marshal = true
+			fmt.Fprintf(&fw, "if b, err = json.Marshal(%s); err != nil {\nreturn nil, fmt.Errorf(\"%s: %%w\", err)\n}\n%s",
+				value, f.prop, add(f.prop, "string(b)"))
+		}
+
+		if nilCheck {
+			fmt.Fprintf(&fw, "}\n")
+		}
+	}
+
+	if marshal {
+		fmt.Fprintf(&w, "var (\nb []byte\nerr error\n)\n")
+	}
+	w.Write(fw.Bytes())
+	if multipart {
+		fmt.Fprintf(&w, "return xmw, nil\n}\n\n")
+	} else {
+		fmt.Fprintf(&w, "return form, nil\n}\n\n")
+	}
+	g.decls.Write(w.Bytes())
+	return nil
+}
+
+// formatExpr formats the scalar value of s as a string, typ is the Go type of value, or empty if it is unknown.
+func (g *openapiGenerator) formatExpr(value, typ string, s *oaSchema) string {
+	switch {
+	case typ == "string":
+		return value
+	case typ == "time.Time":
+		return strings.TrimPrefix(value, "*") + ".Format(time.RFC3339)"
+	case typ == "[]byte":
+		g.imports["base64"] = "encoding/base64"
+		return "base64.StdEncoding.EncodeToString(" + value + ")"
+	case s.typ() == "string" && s.Format == "":
+		return "string(" + value + ")"
+	}
+	return "fmt.Sprint(" + value + ")"
+}
+
+func isScalarSchema(s *oaSchema) bool {
+	if s == nil || len(s.Properties) > 0 || len(s.AllOf) > 0 || len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return false
+	}
+	switch s.typ() {
+	case "string":
+		return s.Format != "binary"
+	case "integer", "number", "boolean":
+		return true
+	}
+	return false
+}
+
+// result returns the type of the content of r, the JSON content is preferred.
+func (g *openapiGenerator) result(r *oaResponse, hint string) (oaResult, error) {
+	r, err := g.response(r)
+	if err != nil {
+		return oaResult{}, err
+	}
+	if len(r.Content) == 0 {
+		return oaResult{}, nil
+	}
+	for _, mt := range sortedKeys(r.Content) {
+		if isJSONMediaType(mt) {
+			typ, err := g.typeExpr(r.Content[mt].Schema, hint)
+			return oaResult{typ: typ, json: true, schema: r.Content[mt].Schema}, err
+		}
+	}
+	return oaResult{typ: "[]byte"}, nil
+}
+
+// errorTypes declares the error types of the unsuccessful responses and decode<Operation>Error.
+func (g *openapiGenerator) errorTypes(name string, op *oaOperation, codes []string) error {
+	var cases bytes.Buffer
+	hasDefault := false
+	for _, code := range codes {
+		status := strings.ToUpper(code)
+		if code == "default" {
+			status, hasDefault = "Default", true
+		}
+		typeName := name + status + "Error"
+		if g.declared[typeName] {
+			return fmt.Errorf("duplicate type %s", typeName)
+		}
+		g.declared[typeName] = true
+
+		result, err := g.result(op.Responses[code], name+status+"Body")
+		if err != nil {
+			return fmt.Errorf("responses.%s: %w", code, err)
+		}
+		r, _ := g.response(op.Responses[code])
+
+		fmt.Fprintf(&g.decls, "// %s is the %s response of %s.\n", typeName, code, name)
+		if r.Description != "" {
+			fmt.Fprintf(&g.decls, "//\n")
+			writeComment(&g.decls, r.Description)
+		}
+		fmt.Fprintf(&g.decls, "type %s struct {\nStatusCode int\n", typeName)
+		if result.typ != "" {
+			fmt.Fprintf(&g.decls, "Body %s\n", result.typ)
+		}
+		fmt.Fprintf(&g.decls, "}\n\n")
+		fmt.Fprintf(&g.decls, "func (e *%s) Error() string {\nreturn fmt.Sprintf(\"%s: %%d %%s\", e.StatusCode, http.StatusText(e.StatusCode))\n}\n\n", typeName, name)
+
+		switch {
+		case code == "default":
+			fmt.Fprintf(&cases, "default:\n")
+		case strings.HasSuffix(status, "XX"):
+			fmt.Fprintf(&cases, "case code/100 == %s:\n", status[:1])
+		default:
+			fmt.Fprintf(&cases, "case code == %s:\n", code)
+		}
+		switch {
+		case result.typ == "":
+			fmt.Fprintf(&cases, "return &%s{StatusCode: code}\n", typeName)
+		case !result.json:
+			fmt.Fprintf(&cases, "return &%s{StatusCode: code, Body: respBody}\n", typeName)
+		default:
+			g.imports["json"] = "encoding/json"
+			fmt.Fprintf(&cases, "e := &%s{StatusCode: code}\n", typeName)
+			fmt.Fprintf(&cases, "if err := json.Unmarshal(respBody, &e.Body); err != nil {\n")
+			fmt.Fprintf(&cases, "return fmt.Errorf(\"%s: decode %%d response: %%w\", code, err)\n}\nreturn e\n", name)
+		}
+	}
+
+	fmt.Fprintf(&g.decls, "func decode%sError(resp *http.Response, respBody []byte) error {\n", name)
+	fmt.Fprintf(&g.decls, "switch code := resp.StatusCode; {\n%s}\n", cases.Bytes())
+	if !hasDefault {
+		fmt.Fprintf(&g.decls, "return &UnexpectedStatusError{Operation: %q, StatusCode: resp.StatusCode, Body: respBody}\n", name)
+	}
+	fmt.Fprintf(&g.decls, "}\n\n")
+	return nil
+}
+
+func isJSONMediaType(mt string) bool {
+	mt, _, _ = strings.Cut(mt, ";")
+	mt = strings.TrimSpace(mt)
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// sortedStatusCodes sorts the exact status codes before the ranges, e.g. "4XX", and "default" is the last.
+func sortedStatusCodes(responses map[string]*oaResponse) []string {
+	codes := sortedKeys(responses)
+	rank := func(code string) int {
+		switch {
+		case code == "default":
+			return 2
+		case strings.HasSuffix(strings.ToUpper(code), "XX"):
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(codes, func(i, j int) bool { return rank(codes[i]) < rank(codes[j]) })
+	return codes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeComment(w *bytes.Buffer, text string) {
+	if text = strings.TrimSpace(text); text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimRightFunc(line, unicode.IsSpace); line == "" {
+			fmt.Fprintf(w, "//\n")
+		} else {
+			fmt.Fprintf(w, "// %s\n", line)
+		}
+	}
+}
+
+var _initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "TLS": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// goName converts s to an exported Go identifier, e.g. "pet_id" and "petId" are "PetID".
+func goName(s string) string {
+	var (
+		words []string
+		word  []rune
+		rs    = []rune(s)
+	)
+	for i, r := range rs {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && len(word) > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])) {
+			words, word = append(words, string(word)), nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	var sb strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); _initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		rs := []rune(w)
+		rs[0] = unicode.ToUpper(rs[0])
+		sb.WriteString(string(rs))
+	}
+	name := sb.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "N" + name
+	}
+	return name
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	used[unique] = true
+	return unique
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	dir := filepath.Join("internal", "openapitest")
+	spec, err := os.ReadFile(filepath.Join(dir, "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generateOpenAPI(spec, "petstore.json", "openapitest", "Client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(filepath.Join(dir, "petstore_xhttp.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s/petstore_xhttp.go is outdated, run go generate:\n%s", dir, got)
+	}
+}
+
+func TestRunOpenAPI_output(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "petstore")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := os.ReadFile(filepath.Join("internal", "openapitest", "petstore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	specFile := filepath.Join(dir, "petstore.json")
+	if err = os.WriteFile(specFile, spec, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = runOpenAPI([]string{specFile}, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile(filepath.Join(dir, "petstore_xhttp.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(src, []byte("\npackage petstore\n")) {
+		t.Fatalf("package of the output:\n%s", src)
+	}
+}
+
+func TestGenerateOpenAPI_errors(t *testing.T) {
+	const op = `"responses": {"204": {"description": "ok"}}`
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			spec:    "openapi: 3.0.3",
+			wantErr: "only JSON specs are supported",
+		},
+		{
+			name:    "swagger",
+			spec:    `{"swagger": "2.0"}`,
+			wantErr: `unsupported openapi version ""`,
+		},
+		{
+			name:    "unresolved_ref",
+			spec:    `{"openapi": "3.1.0", "components": {"schemas": {"A": {"$ref": "#/components/schemas/B"}}}}`,
+			wantErr: `components.schemas.A: unresolved $ref "#/components/schemas/B"`,
+		},
+		{
+			name:    "cyclic_response_ref",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"get": {"responses": {"200": {"$ref": "#/components/responses/A"}}}}}, "components": {"responses": {"A": {"$ref": "#/components/responses/B"}, "B": {"$ref": "#/components/responses/A"}}}}`,
+			wantErr: "more than 32 hops",
+		},
+		{
+			name:    "cyclic_parameter_ref",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"get": {"parameters": [{"$ref": "#/components/parameters/A"}], ` + op + `}}}, "components": {"parameters": {"A": {"$ref": "#/components/parameters/A"}}}}`,
+			wantErr: "more than 32 hops",
+		},
+		{
+			name:    "cookie",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"get": {"parameters": [{"name": "s", "in": "cookie", "schema": {"type": "string"}}], ` + op + `}}}}`,
+			wantErr: "GET /a: parameter 's': cookie parameters are not supported",
+		},
+		{
+			name:    "optional_path",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a/{id}": {"get": {"parameters": [{"name": "id", "in": "path", "schema": {"type": "string"}}], ` + op + `}}}}`,
+			wantErr: "path parameter 'id' must be required",
+		},
+		{
+			name:    "media_type",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"put": {"requestBody": {"content": {"application/octet-stream": {}}}, ` + op + `}}}}`,
+			wantErr: "PUT /a: requestBody: unsupported media types [application/octet-stream]",
+		},
+		{
+			name:    "form_array",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"put": {"requestBody": {"content": {"application/x-www-form-urlencoded": {"schema": {"type": "array"}}}}, ` + op + `}}}}`,
+			wantErr: "the schema of form must be an object",
+		},
+		{
+			name:    "form_binary",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"put": {"requestBody": {"content": {"application/x-www-form-urlencoded": {"schema": {"properties": {"f": {"type": "string", "format": "binary"}}}}}}, ` + op + `}}}}`,
+			wantErr: "a binary string must be sent as multipart/form-data",
+		},
+		{
+			name:    "duplicate_operation",
+			spec:    `{"openapi": "3.1.0", "paths": {"/a": {"get": {"operationId": "x", ` + op + `}, "put": {"operationId": "x", ` + op + `}}}}`,
+			wantErr: "PUT /a: operation X is declared by GET /a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := generateOpenAPI([]byte(tt.spec), "spec.json", "api", "Client")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for s, want := range map[string]string{
+		"petId":                    "PetID",
+		"pet_id":                   "PetID",
+		"X-Request-ID":             "XRequestID",
+		"listPets":                 "ListPets",
+		"get /pets/{petId}/photos": "GetPetsPetIDPhotos",
+		"HTTPServer":               "HTTPServer",
+		"2fa":                      "N2fa",
+		"api-url":                  "APIURL",
+	} {
+		if got := goName(s); got != want {
+			t.Errorf("goName(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package xhttpclient
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+type CurlOptions struct {
+	// RedactHeaders are rendered with the value 'REDACTED', e.g. "Authorization".
+	RedactHeaders []string
+
+	// BodyFile receives the body when it is longer than BodyFileThreshold or binary,
+	// the command references it with '--data-binary @BodyFile'.
+	BodyFile          string
+	BodyFileThreshold int
+}
+
+// Curl renders xReq as a curl command, with the client headers, the encoded body,
+// the content digest and the signature, exactly as Do would send it.
+// Like Do, it consumes a pooled xReq, see NewPooled.
+func (xc *XClient) Curl(xReq *XRequestBuilder, opts *CurlOptions) (string, error) {
+	return xc.CurlWithBodyCodec(xc.bodyCodecPool, xReq, opts)
+}
+
+func (xc *XClient) CurlWithBodyCodec(bodyCodec BodyCodec, xReq *XRequestBuilder, opts *CurlOptions) (string, error) {
+	if opts == nil {
+		opts = new(CurlOptions)
+	}
+
+	bc := bodyCodec.Get()
+	defer bodyCodec.Put(bc)
+
+	xReq = xReq.sendable()
+	xc.initXReq(xReq)
+	req, cancel, err := xc.prepare(bc, xReq)
+	defer cancel()
+	if err != nil {
+		return "", err
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"curl"}
+	switch {
+	case req.Method == http.MethodHead:
+		args = append(args, "--head")
+	case req.Method != http.MethodGet || body != nil:
+		args = append(args, "-X", req.Method)
+	}
+	args = append(args, shellQuote(req.URL.String()))
+
+	redact := make(map[string]bool, len(opts.RedactHeaders))
+	for _, k := range opts.RedactHeaders {
+		redact[http.CanonicalHeaderKey(k)] = true
+	}
+	for _, k := range sortedHeaderKeys(req.Header) {
+		if k == "Content-Length" {
+			// curl computes it from the body
+			continue
+		}
+		for _, v := range req.Header[k] {
+			if redact[k] {
+				v = "REDACTED"
+			}
+			args = append(args, "-H", shellQuote(k+": "+v))
+		}
+	}
+
+	if body != nil {
+		large := opts.BodyFileThreshold > 0 && len(body) > opts.BodyFileThreshold
+		binary := !utf8.Valid(body) || strings.ContainsRune(string(body), 0)
+		switch {
+		case (large || binary) && opts.BodyFile != "":
+			if err = os.WriteFile(opts.BodyFile, body, 0o644); err != nil {
+				return "", err
+			}
+			args = append(args, "--data-binary", shellQuote("@"+opts.BodyFile))
+		case binary:
+			return "", errors.New("curl: binary body requires 'CurlOptions.BodyFile'")
+		default:
+			args = append(args, "--data-raw", shellQuote(string(body)))
+		}
+	}
+
+	return strings.Join(args, " "), nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@=,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
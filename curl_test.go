@@ -0,0 +1,83 @@
+package xhttpclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestXClient_Curl(t *testing.T) {
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		SetBasicAuth("user", "pass").
+		SetHeader("X-Tenant", "it's me")
+
+	tests := []struct {
+		name string
+		xReq *XRequestBuilder
+		opts *CurlOptions
+		want string
+	}{
+		{
+			name: "get",
+			xReq: NewGet().Path("search").SetQuery("q", "a b").AddQuery("page", "1"),
+			opts: &CurlOptions{RedactHeaders: []string{"authorization"}},
+			want: `curl 'https://api.example.com/search?page=1&q=a+b' -H 'Accept: application/json; charset=utf-8' -H 'Authorization: REDACTED' -H 'Content-Type: application/json; charset=utf-8' -H 'X-Tenant: it'\''s me'`,
+		},
+		{
+			name: "post",
+			xReq: NewPost().Path("users").Body(map[string]string{"name": "x"}),
+			want: `curl -X POST https://api.example.com/users -H 'Accept: application/json; charset=utf-8' -H 'Authorization: Basic dXNlcjpwYXNz' -H 'Content-Type: application/json; charset=utf-8' -H 'X-Tenant: it'\''s me' --data-raw '{"name":"x"}` + "\n'",
+		},
+		{
+			name: "head",
+			xReq: NewHead(),
+			opts: &CurlOptions{RedactHeaders: []string{"Authorization", "X-Tenant"}},
+			want: `curl --head https://api.example.com -H 'Accept: application/json; charset=utf-8' -H 'Authorization: REDACTED' -H 'Content-Type: application/json; charset=utf-8' -H 'X-Tenant: REDACTED'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cli.Curl(tt.xReq, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("Curl() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXClient_CurlWithBodyCodec_Multipart(t *testing.T) {
+	cli := NewClient().BaseURL("https://api.example.com")
+	bodyFile := filepath.Join(t.TempDir(), "body.bin")
+
+	mw := NewMultipartWriter().
+		SetBoundary("boundary").
+		WriteWithFieldValue("k1", "v1").
+		WriteWithField("bin", strings.NewReader("\x00\xff"))
+	got, err := cli.CurlWithBodyCodec(BodyCodecMultipart, NewPost().Path("upload").Body(mw), &CurlOptions{BodyFile: bodyFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `curl -X POST https://api.example.com/upload -H 'Accept: application/json; charset=utf-8' -H 'Content-Type: multipart/form-data; boundary=boundary' --data-binary @` + bodyFile
+	if got != want {
+		t.Fatalf("Curl() =\n%s\nwant\n%s", got, want)
+	}
+	body, err := os.ReadFile(bodyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "name=\"k1\"\r\n\r\nv1\r\n--boundary") || !strings.Contains(string(body), "\x00\xff") {
+		t.Fatalf("body = %q", body)
+	}
+
+	_, err = cli.CurlWithBodyCodec(BodyCodecMultipart, NewPost().Body(NewMultipartWriter().WriteWithFieldValue("bin", "\xff")), nil)
+	if err == nil {
+		t.Fatal("binary body without 'BodyFile' should fail")
+	}
+}
@@ -0,0 +1,280 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	urlpkg "net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type DebugOptions struct {
+	// Level of the log records, the zero value is slog.LevelInfo.
+	Level slog.Level
+	// OnlyRequests only logs the requests enabled by XRequestBuilder.Debug(true),
+	// otherwise every request is logged unless disabled by XRequestBuilder.Debug(false).
+	OnlyRequests bool
+	// MaxBodySize truncates logged bodies, the default is 4096, negative omits bodies.
+	// The response bodies are captured up to it, the truncated JSON bodies holding a RedactFields key are omitted.
+	MaxBodySize int
+	// RedactHeaders are logged with the value 'REDACTED',
+	// the default is 'Authorization', 'Proxy-Authorization', 'Cookie' and 'Set-Cookie'.
+	RedactHeaders []string
+	// RedactFields are the JSON object keys (at any depth) and form fields logged with the value 'REDACTED'.
+	RedactFields []string
+}
+
+var _defaultDebugRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type debugLogger struct {
+	logger        *slog.Logger
+	level         slog.Level
+	onlyRequests  bool
+	maxBodySize   int
+	redactHeaders map[string]bool
+	redactFields  map[string]bool
+}
+
+// WithDebug logs the request line, headers and encoded body before sending,
+// then the response status, headers, body and duration once the body is read or closed.
+// A nil handler disables it.
+func (xc *XClient) WithDebug(handler slog.Handler, opts *DebugOptions) *XClient {
+	if handler == nil {
+		xc.debug = nil
+		return xc
+	}
+	if opts == nil {
+		opts = new(DebugOptions)
+	}
+
+	d := &debugLogger{
+		logger:        slog.New(handler),
+		level:         opts.Level,
+		onlyRequests:  opts.OnlyRequests,
+		maxBodySize:   opts.MaxBodySize,
+		redactHeaders: make(map[string]bool),
+		redactFields:  make(map[string]bool, len(opts.RedactFields)),
+	}
+	if d.maxBodySize == 0 {
+		d.maxBodySize = 4096
+	}
+	redactHeaders := opts.RedactHeaders
+	if redactHeaders == nil {
+		redactHeaders = _defaultDebugRedactHeaders
+	}
+	for _, k := range redactHeaders {
+		d.redactHeaders[http.CanonicalHeaderKey(k)] = true
+	}
+	for _, k := range opts.RedactFields {
+		d.redactFields[k] = true
+	}
+
+	xc.debug = d
+	return xc
+}
+
+// Debug enables or disables the debug logging of this request, see XClient.WithDebug.
+func (xr *XRequestBuilder) Debug(enabled bool) *XRequestBuilder {
+	if enabled {
+		xr.debug = debugOn
+	} else {
+		xr.debug = debugOff
+	}
+	return xr
+}
+
+const (
+	debugUnset int8 = iota
+	debugOn
+	debugOff
+)
+
+func (d *debugLogger) enabled(xr *XRequestBuilder) bool {
+	if d == nil {
+		return false
+	}
+	if d.onlyRequests {
+		return xr.debug == debugOn
+	}
+	return xr.debug != debugOff
+}
+
+func (d *debugLogger) logRequest(req *http.Request) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		d.headerAttr(req.Header),
+	}
+	if body, err := readRequestBody(req); err == nil && body != nil {
+		attrs = append(attrs, d.bodyAttr(req.Header, body, int64(len(body))))
+	}
+	d.logger.LogAttrs(req.Context(), d.level, "xhttpclient request", attrs...)
+}
+
+func (d *debugLogger) logResponse(req *http.Request, resp *http.Response, err error, start time.Time) {
+	ctx := req.Context()
+	if err != nil {
+		d.logger.LogAttrs(ctx, d.level, "xhttpclient response",
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.String()),
+			slog.String("error", err.Error()),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return
+	}
+
+	header := time.Since(start)
+	log := func(body slog.Attr) {
+		d.logger.LogAttrs(ctx, d.level, "xhttpclient response",
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.String()),
+			slog.Int("status", resp.StatusCode),
+			d.headerAttr(resp.Header),
+			body,
+			slog.Duration("header_duration", header),
+			slog.Duration("duration", time.Since(start)),
+		)
+	}
+	if d.maxBodySize < 0 {
+		// the body is not logged, only counted
+		nb := &notifyBody{ReadCloser: resp.Body}
+		nb.finish = func() { log(slog.Int64("body_size", nb.n)) }
+		resp.Body = nb
+		return
+	}
+	resp.Body = &captureBody{
+		ReadCloser: resp.Body,
+		limit:      d.maxBodySize,
+		finish: func(body []byte, size int64) {
+			log(d.bodyAttr(resp.Header, body, size))
+		},
+	}
+}
+
+func (d *debugLogger) headerAttr(header http.Header) slog.Attr {
+	attrs := make([]any, 0, len(header))
+	for _, k := range sortedHeaderKeys(header) {
+		v := strings.Join(header[k], ", ")
+		if d.redactHeaders[k] {
+			v = "REDACTED"
+		}
+		attrs = append(attrs, slog.String(k, v))
+	}
+	return slog.Group("header", attrs...)
+}
+
+// bodyAttr logs body, the captured prefix of the size bytes of the whole body.
+func (d *debugLogger) bodyAttr(header http.Header, body []byte, size int64) slog.Attr {
+	if d.maxBodySize < 0 {
+		return slog.Int64("body_size", size)
+	}
+	truncated := size > int64(len(body))
+	if truncated {
+		body = trimPartialRune(body)
+	}
+	if !utf8.Valid(body) || bytes.IndexByte(body, 0) >= 0 {
+		return slog.String("body", fmt.Sprintf("<binary %d bytes>", size))
+	}
+
+	if len(d.redactFields) != 0 {
+		contentType := header.Get("Content-Type")
+		if truncated {
+			if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" {
+				// the last field may be cut
+				body = body[:max(bytes.LastIndexByte(body, '&'), 0)]
+			} else if d.hasRedactField(body) {
+				// a partial JSON document can not be redacted
+				return slog.String("body", fmt.Sprintf("<%d bytes not redacted>", size))
+			}
+		}
+		omitted := size - int64(len(body))
+		body = d.redactBody(contentType, body)
+		size = int64(len(body)) + omitted
+	}
+	if len(body) > d.maxBodySize {
+		cut := d.maxBodySize
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		body = body[:cut]
+	}
+	if omitted := size - int64(len(body)); omitted > 0 {
+		return slog.String("body", fmt.Sprintf("%s... (%d bytes truncated)", body, omitted))
+	}
+	return slog.String("body", string(body))
+}
+
+func (d *debugLogger) hasRedactField(body []byte) bool {
+	for k := range d.redactFields {
+		if bytes.Contains(body, []byte(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// trimPartialRune drops the incomplete rune at the end of b.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+func (d *debugLogger) redactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := urlpkg.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for k := range values {
+			if d.redactFields[k] {
+				values[k] = []string{"REDACTED"}
+			}
+		}
+		return []byte(values.Encode())
+	case json.Valid(body):
+		var v any
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err != nil {
+			return body
+		}
+		redacted, err := json.Marshal(d.redactJSON(v))
+		if err != nil {
+			return body
+		}
+		return redacted
+	}
+	return body
+}
+
+func (d *debugLogger) redactJSON(v any) any {
+	switch tv := v.(type) {
+	case map[string]any:
+		for k, vv := range tv {
+			if d.redactFields[k] {
+				tv[k] = "REDACTED"
+			} else {
+				tv[k] = d.redactJSON(vv)
+			}
+		}
+	case []any:
+		for i := range tv {
+			tv[i] = d.redactJSON(tv[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,180 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestXClient_WithDebug(t *testing.T) {
+	var buf bytes.Buffer
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		SetHeader("Authorization", "Bearer secret").
+		WithDebug(slog.NewJSONHandler(&buf, nil), &DebugOptions{
+			MaxBodySize:  40,
+			RedactFields: []string{"password"},
+		}).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeValueJSON)
+			if r.URL.Path == "/binary" {
+				w.Write([]byte{0, 1, 2, 3})
+				return
+			}
+			io.WriteString(w, `{"token":"abcdefghijklmnopqrstuvwxyz0123456789"}`)
+		}))
+
+	var successV map[string]any
+	if _, _, err := cli.Do(&successV, nil,
+		NewPost().Path("login").Body(map[string]any{"user": "u", "password": "p"})); err != nil {
+		t.Fatal(err)
+	}
+
+	records := decodeDebugRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	req, resp := records[0], records[1]
+
+	if req["msg"] != "xhttpclient request" || req["method"] != http.MethodPost || req["url"] != "https://api.example.com/login" {
+		t.Fatalf("request = %v", req)
+	}
+	if h := req["header"].(map[string]any); h["Authorization"] != "REDACTED" || h["Content-Type"] != ContentTypeValueJSON {
+		t.Fatalf("request header = %v", h)
+	}
+	if req["body"] != `{"password":"REDACTED","user":"u"}` {
+		t.Fatalf("request body = %v", req["body"])
+	}
+
+	if resp["msg"] != "xhttpclient response" || resp["status"] != float64(http.StatusOK) {
+		t.Fatalf("response = %v", resp)
+	}
+	if body := resp["body"].(string); !strings.HasPrefix(body, `{"token":"abcdefghijklmnopqrstuvwxyz0123`) || !strings.HasSuffix(body, "(8 bytes truncated)") {
+		t.Fatalf("response body = %v", body)
+	}
+	if _, ok := resp["duration"]; !ok {
+		t.Fatalf("response = %v, want duration", resp)
+	}
+
+	// binary
+	if _, _, err := cli.DoOnceWithBodyCodec(BodyCodecJSON, &[]byte{}, &[]byte{}, NewGet().Path("binary")); err == nil {
+		t.Fatal("want decode error")
+	}
+	records = decodeDebugRecords(t, &buf)
+	if len(records) != 2 || records[1]["body"] != "<binary 4 bytes>" {
+		t.Fatalf("records = %v", records)
+	}
+
+	// disabled per request
+	if _, _, err := cli.Do(&successV, nil, NewGet().Path("quiet").Debug(false)); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("logged %s", buf.String())
+	}
+}
+
+func TestDebugOptions_OnlyRequests(t *testing.T) {
+	var buf bytes.Buffer
+	cli := NewClient().
+		WithDebug(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), &DebugOptions{
+			Level:        slog.LevelDebug,
+			OnlyRequests: true,
+			MaxBodySize:  -1,
+		}).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, `{}`)
+		}))
+
+	var successV map[string]any
+	if _, _, err := cli.Do(&successV, nil, NewGet().Path("a")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("logged %s", buf.String())
+	}
+
+	if _, _, err := cli.Do(&successV, nil, NewGet().Path("b").Debug(true)); err != nil {
+		t.Fatal(err)
+	}
+	records := decodeDebugRecords(t, &buf)
+	if len(records) != 2 || records[0]["level"] != "DEBUG" || records[1]["body_size"] != float64(2) {
+		t.Fatalf("records = %v", records)
+	}
+	if _, ok := records[1]["body"]; ok {
+		t.Fatalf("response = %v, want no body", records[1])
+	}
+}
+
+func TestXClient_WithDebug_largeBody(t *testing.T) {
+	var buf bytes.Buffer
+	size := 1 << 20
+	cli := NewClient().
+		WithDebug(slog.NewJSONHandler(&buf, nil), &DebugOptions{MaxBodySize: 16, RedactFields: []string{"password"}}).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/secret":
+				io.WriteString(w, `{"password":"secret","user":"u"}`)
+			case "/utf8":
+				io.WriteString(w, "0123456789abcde\u00e9fgh")
+			default:
+				w.Write(bytes.Repeat([]byte("a"), size))
+			}
+		}))
+
+	_, resp, cancel, err := cli.DoWithRaw(NewGet().Path("/large"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	capture := resp.Body.(*captureBody)
+	n, err := io.Copy(io.Discard, resp.Body)
+	cancel()
+	if err != nil || n != int64(size) {
+		t.Fatalf("read %d bytes, err = %v", n, err)
+	}
+	if capture.buf.Len() != 16 {
+		t.Fatalf("captured %d bytes, want 16", capture.buf.Len())
+	}
+	records := decodeDebugRecords(t, &buf)
+	if want := strings.Repeat("a", 16) + "... (1048560 bytes truncated)"; len(records) != 2 || records[1]["body"] != want {
+		t.Fatalf("records = %v, want body %q", records, want)
+	}
+
+	var successV map[string]any
+	if _, _, err = cli.Do(&successV, nil, NewGet().Path("/secret")); err != nil {
+		t.Fatal(err)
+	}
+	if records = decodeDebugRecords(t, &buf); len(records) != 2 || records[1]["body"] != "<32 bytes not redacted>" {
+		t.Fatalf("records = %v", records)
+	}
+
+	// the rune cut by the limit is not logged
+	_, resp, cancel, err = cli.DoWithRaw(NewGet().Path("/utf8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	cancel()
+	if records = decodeDebugRecords(t, &buf); len(records) != 2 || records[1]["body"] != "0123456789abcde... (5 bytes truncated)" {
+		t.Fatalf("records = %v", records)
+	}
+}
+
+func decodeDebugRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var r map[string]any
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	buf.Reset()
+	return records
+}
@@ -0,0 +1,175 @@
+package xhttpclient
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Digest Fields, see https://www.rfc-editor.org/rfc/rfc9530
+
+const (
+	DigestSHA256 = "sha-256"
+	DigestSHA512 = "sha-512"
+)
+
+type DigestVerification int
+
+const (
+	DigestVerificationOff DigestVerification = iota
+	// DigestVerificationIfPresent verifies 'Content-Digest' and 'Repr-Digest' when the response has them.
+	DigestVerificationIfPresent
+	// DigestVerificationRequired also rejects responses without a digest of a supported algorithm.
+	DigestVerificationRequired
+)
+
+var (
+	ErrDigestMissing  = errors.New("missing digest")
+	ErrDigestMismatch = errors.New("digest mismatch")
+)
+
+type DigestError struct {
+	// Field is 'Content-Digest' or 'Repr-Digest'
+	Field     string
+	Algorithm string
+	Err       error
+}
+
+func (e *DigestError) Error() string {
+	if e.Algorithm == "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %s", e.Field, e.Algorithm, e.Err)
+}
+
+func (e *DigestError) Unwrap() error {
+	return e.Err
+}
+
+var _digestHashes = map[string]func() hash.Hash{
+	DigestSHA256: sha256.New,
+	DigestSHA512: sha512.New,
+}
+
+// WithContentDigest sets the 'Content-Digest' header of requests with a body,
+// it is computed before RequestSigner so that it can be covered by a signature.
+func (xc *XClient) WithContentDigest(algorithms ...string) *XClient {
+	xc.digestAlgs = append([]string{}, algorithms...)
+	return xc
+}
+
+// WithDigestVerification verifies the digests of responses before ResponseVerifier and decoding,
+// like ResponseVerifier it only applies to Do and DoOnceWithBodyCodec.
+//
+// Responses transparently decompressed by net/http are treated as without digest,
+// since the received content is no longer available.
+func (xc *XClient) WithDigestVerification(v DigestVerification) *XClient {
+	xc.digestVerification = v
+	return xc
+}
+
+func setContentDigest(req *http.Request, algorithms []string) error {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	if req.GetBody == nil {
+		return errors.New("request body is not replayable")
+	}
+
+	hashes := make([]hash.Hash, 0, len(algorithms))
+	writers := make([]io.Writer, 0, len(algorithms))
+	for _, alg := range algorithms {
+		newHash, ok := _digestHashes[alg]
+		if !ok {
+			return fmt.Errorf("unsupported algorithm %q", alg)
+		}
+		h := newHash()
+		hashes = append(hashes, h)
+		writers = append(writers, h)
+	}
+
+	// the codecs encode into pooled buffers, GetBody reads them without copying
+	rc, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if _, err = io.Copy(io.MultiWriter(writers...), rc); err != nil {
+		return err
+	}
+
+	members := make([]string, 0, len(hashes))
+	for i, h := range hashes {
+		members = append(members, algorithms[i]+"="+sfSerializeBareItem(h.Sum(nil)))
+	}
+	req.Header.Set("Content-Digest", strings.Join(members, ", "))
+	return nil
+}
+
+func verifyDigest(resp *http.Response, body []byte, v DigestVerification) error {
+	switch {
+	case v == DigestVerificationOff:
+		return nil
+	case resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified:
+		return nil
+	case resp.Request != nil && resp.Request.Method == http.MethodHead:
+		return nil
+	}
+
+	fields := []string{"Content-Digest"}
+	if resp.StatusCode != http.StatusPartialContent {
+		// without range requests the representation data is the content
+		fields = append(fields, "Repr-Digest")
+	}
+
+	verified := false
+	if !resp.Uncompressed {
+		for _, field := range fields {
+			ok, err := verifyDigestField(resp.Header, field, body)
+			if err != nil {
+				return err
+			}
+			verified = verified || ok
+		}
+	}
+
+	if !verified && v == DigestVerificationRequired {
+		return &DigestError{Field: strings.Join(fields, ", "), Err: ErrDigestMissing}
+	}
+	return nil
+}
+
+func verifyDigestField(header http.Header, field string, body []byte) (verified bool, err error) {
+	values := header.Values(field)
+	if len(values) == 0 {
+		return false, nil
+	}
+
+	members, err := sfParseDictionary(strings.Join(values, ", "))
+	if err != nil {
+		return false, &DigestError{Field: field, Err: err}
+	}
+	for _, m := range members {
+		newHash, ok := _digestHashes[m.key]
+		if !ok {
+			continue
+		}
+		want, ok := m.item.value.([]byte)
+		if !ok {
+			return false, &DigestError{Field: field, Algorithm: m.key, Err: errors.New("malformed value")}
+		}
+		h := newHash()
+		h.Write(body)
+		if subtle.ConstantTimeCompare(h.Sum(nil), want) != 1 {
+			return false, &DigestError{Field: field, Algorithm: m.key, Err: ErrDigestMismatch}
+		}
+		verified = true
+	}
+	return verified, nil
+}
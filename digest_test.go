@@ -0,0 +1,107 @@
+package xhttpclient
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	urlpkg "net/url"
+	"testing"
+)
+
+func TestXClient_WithContentDigest(t *testing.T) {
+	var gotDigest string
+	var gotBody []byte
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotDigest = r.Header.Get("Content-Digest")
+			gotBody, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+	defer ts.Close()
+
+	cli := NewClient().BaseURL(ts.URL).WithContentDigest(DigestSHA256, DigestSHA512)
+
+	formData := urlpkg.Values{"hello": []string{"world"}}
+	var successV any
+	if _, _, err := cli.DoOnceWithBodyCodec(BodyCodecFormUrlencodedAndJSON, &successV, nil, NewPost().Body(formData)); err != nil {
+		t.Fatal(err)
+	}
+
+	sum256, sum512 := sha256.Sum256(gotBody), sha512.Sum512(gotBody)
+	want := "sha-256=:" + base64.StdEncoding.EncodeToString(sum256[:]) + ":, " +
+		"sha-512=:" + base64.StdEncoding.EncodeToString(sum512[:]) + ":"
+	if string(gotBody) != "hello=world" {
+		t.Fatalf("body = %s, want %s", gotBody, "hello=world")
+	}
+	if gotDigest != want {
+		t.Fatalf("Content-Digest = %s, want %s", gotDigest, want)
+	}
+
+	if _, _, err := cli.Do(&successV, nil, NewGet()); err != nil {
+		t.Fatal(err)
+	}
+	if gotDigest != "" {
+		t.Fatalf("Content-Digest = %s, want empty", gotDigest)
+	}
+}
+
+func TestXClient_WithDigestVerification(t *testing.T) {
+	body := `{"hello":"world"}`
+	sum := sha256.Sum256([]byte(body))
+	digest := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"
+
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/content":
+				w.Header().Set("Content-Digest", digest)
+			case "/repr":
+				w.Header().Set("Repr-Digest", "unknown=:AA==:, "+digest)
+			case "/corrupted":
+				w.Header().Set("Content-Digest", digest)
+				io.WriteString(w, `{"hello":"World"}`)
+				return
+			}
+			io.WriteString(w, body)
+		}),
+	)
+	defer ts.Close()
+
+	tests := []struct {
+		path    string
+		v       DigestVerification
+		wantErr error
+	}{
+		{path: "/content", v: DigestVerificationRequired},
+		{path: "/repr", v: DigestVerificationRequired},
+		{path: "/none", v: DigestVerificationIfPresent},
+		{path: "/none", v: DigestVerificationRequired, wantErr: ErrDigestMissing},
+		{path: "/corrupted", v: DigestVerificationOff},
+		{path: "/corrupted", v: DigestVerificationIfPresent, wantErr: ErrDigestMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cli := NewClient().BaseURL(ts.URL).WithDigestVerification(tt.v)
+
+			var successV map[string]string
+			_, _, err := cli.Do(&successV, nil, NewGet().Path(tt.path))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			var digestErr *DigestError
+			if !errors.As(err, &digestErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package xhttpclient
+
+// Discard skips decoding the response body if it is passed as the successV, the wrongV or an OnStatus target,
+// the body is still read and returned by Do.
+//
+//	_, _, err := cli.Do(xhttpclient.Discard, nil, xhttpclient.NewDelete().Path("users/1"))
+var Discard any = discard{}
+
+type discard struct{}
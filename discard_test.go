@@ -0,0 +1,38 @@
+package xhttpclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestDiscard(t *testing.T) {
+	cli := NewClient().BaseURL("https://example.com").WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		io.WriteString(w, "not json")
+	}))
+	newReq := func(code int) *XRequestBuilder {
+		return NewGet().SetQuery("code", strconv.Itoa(code))
+	}
+
+	_, body, err := cli.Do(Discard, nil, newReq(http.StatusOK))
+	if err != nil || string(body) != "not json" {
+		t.Fatalf("success: body = %q, err = %v", body, err)
+	}
+	if _, _, err = cli.Do(Discard, Discard, newReq(http.StatusBadRequest)); err != nil {
+		t.Fatalf("wrong: err = %v", err)
+	}
+	if _, _, err = cli.Do(Discard, Discard, newReq(http.StatusBadGateway)); err != nil {
+		t.Fatalf("unsuccessful: err = %v", err)
+	}
+	if _, _, err = cli.Do(Discard, nil, newReq(http.StatusConflict).OnStatus(http.StatusConflict, Discard)); err != nil {
+		t.Fatalf("OnStatus: err = %v", err)
+	}
+
+	var v map[string]any
+	if _, _, err = cli.Do(&v, nil, newReq(http.StatusOK)); err == nil {
+		t.Fatalf("decoded without Discard: err = %v", err)
+	}
+}
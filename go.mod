@@ -1,3 +1,5 @@
 module github.com/electricbubble/xhttpclient
 
-go 1.19
+go 1.21
+
+require gopkg.in/yaml.v3 v3.0.1
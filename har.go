@@ -0,0 +1,390 @@
+package xhttpclient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	urlpkg "net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// HTTP Archive 1.2, see http://www.softwareishard.com/blog/har-12-spec/
+
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator HARCreator  `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ServerIPAddress string      `json:"serverIPAddress,omitempty"`
+	Connection      string      `json:"connection,omitempty"`
+	// Error is the error of the round trip, it is not part of the spec.
+	Error string `json:"_error,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARCookie    `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARCookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []HARNameValue `json:"params"`
+	Text     string         `json:"text"`
+	Comment  string         `json:"comment,omitempty"`
+	// Encoding is "base64" for a binary body like HARContent.Encoding, it is not part of the spec.
+	Encoding string `json:"_encoding,omitempty"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// HARTimings are in milliseconds, -1 if not available.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// Bytes returns the decoded text of the content.
+func (c HARContent) Bytes() []byte {
+	if c.Encoding == "base64" {
+		b, _ := base64.StdEncoding.DecodeString(c.Text)
+		return b
+	}
+	return []byte(c.Text)
+}
+
+// Bytes returns the decoded text of the post data.
+func (pd HARPostData) Bytes() []byte {
+	return HARContent{Text: pd.Text, Encoding: pd.Encoding}.Bytes()
+}
+
+func ReadHARFile(name string) (*HAR, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	h := new(HAR)
+	if err = json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// HARRecorder captures the traffic of XClient, an entry is completed once the response body is read to EOF or closed.
+// The captured bodies are truncated to 1 MiB by default, see WithMaxBodySize.
+type HARRecorder struct {
+	mu          sync.Mutex
+	har         HAR
+	maxBodySize int
+}
+
+func NewHARRecorder() *HARRecorder {
+	return &HARRecorder{
+		har: HAR{Log: HARLog{
+			Version: "1.2",
+			Creator: HARCreator{Name: "xhttpclient"},
+			Entries: make([]*HAREntry, 0),
+		}},
+		maxBodySize: 1 << 20,
+	}
+}
+
+// WithMaxBodySize truncates the captured bodies to n bytes, noted by the comment of the content,
+// a non-positive n captures the whole bodies including the endless streams of DoWithRaw.
+func (h *HARRecorder) WithMaxBodySize(n int) *HARRecorder {
+	h.maxBodySize = n
+	return h
+}
+
+func (xc *XClient) WithHARRecorder(h *HARRecorder) *XClient {
+	xc.har = h
+	return xc
+}
+
+// HAR returns a copy of the captured log.
+func (h *HARRecorder) HAR() *HAR {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cp := h.har
+	cp.Log.Entries = make([]*HAREntry, 0, len(h.har.Log.Entries))
+	for _, e := range h.har.Log.Entries {
+		e := *e
+		cp.Log.Entries = append(cp.Log.Entries, &e)
+	}
+	return &cp
+}
+
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(h.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+func (h *HARRecorder) WriteFile(name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = h.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type harRoundTrip struct {
+	recorder *HARRecorder
+	entry    *HAREntry
+	start    time.Time
+	tracer   *timingTracer
+}
+
+// begin is called right before the request is sent, tracer is optional.
+func (h *HARRecorder) begin(req *http.Request, tracer *timingTracer) *harRoundTrip {
+	entry := &HAREntry{
+		StartedDateTime: time.Now(),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: harValues(req.URL.Query()),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Timings: HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1},
+	}
+	if body, err := readRequestBody(req); err == nil && body != nil {
+		entry.Request.BodySize = len(body)
+		entry.Request.PostData = harPostData(req.Header.Get("Content-Type"), body, h.maxBodySize)
+	}
+
+	h.mu.Lock()
+	h.har.Log.Entries = append(h.har.Log.Entries, entry)
+	h.mu.Unlock()
+
+	return &harRoundTrip{recorder: h, entry: entry, start: entry.StartedDateTime, tracer: tracer}
+}
+
+// end is called once the response header is received, it captures the body while it is read.
+func (rt *harRoundTrip) end(resp *http.Response, err error) {
+	waited := time.Since(rt.start)
+
+	rt.recorder.mu.Lock()
+	defer rt.recorder.mu.Unlock()
+
+	e := rt.entry
+	e.Timings.Send = 0
+	e.Timings.Wait = harMillis(waited)
+	if rt.tracer != nil {
+		rt.setTimings(rt.tracer.timing())
+	}
+	e.Time = harMillis(waited)
+	if err != nil {
+		e.Error = err.Error()
+		e.Response = HARResponse{Cookies: []HARCookie{}, Headers: []HARNameValue{}, HeadersSize: -1, BodySize: -1}
+		return
+	}
+
+	e.Response = HARResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies(resp.Cookies()),
+		Headers:     harHeaders(resp.Header),
+		Content:     HARContent{Size: -1, MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	resp.Body = &captureBody{
+		ReadCloser: resp.Body,
+		limit:      rt.recorder.maxBodySize,
+		finish: func(body []byte, size int64) {
+			received := time.Since(rt.start) - waited
+
+			rt.recorder.mu.Lock()
+			defer rt.recorder.mu.Unlock()
+			e.Timings.Receive = harMillis(received)
+			e.Time = harMillis(waited + received)
+			e.Response.BodySize = int(size)
+			e.Response.Content.Size = int(size)
+			e.Response.Content.Text, e.Response.Content.Encoding = harText(body)
+			e.Response.Content.Comment = harTruncated(len(body), size)
+		},
+	}
+}
+
+func (rt *harRoundTrip) setTimings(t Timing) {
+	e := rt.entry
+	if t.RemoteAddr != "" {
+		if host, _, err := net.SplitHostPort(t.RemoteAddr); err == nil {
+			e.ServerIPAddress = host
+		}
+		e.Connection = t.LocalAddr
+	}
+	if t.TimeToFirstByte == 0 {
+		// not traced, e.g. a custom transport
+		return
+	}
+
+	e.Timings.Blocked = harMillis(t.Blocked)
+	if !t.ConnReused {
+		if t.DNS > 0 {
+			e.Timings.DNS = harMillis(t.DNS)
+		}
+		if t.Connect > 0 {
+			// connect includes ssl
+			e.Timings.Connect = harMillis(t.Connect + t.TLSHandshake)
+		}
+		if t.TLSHandshake > 0 {
+			e.Timings.SSL = harMillis(t.TLSHandshake)
+		}
+	}
+	e.Timings.Send = harMillis(t.Send)
+	e.Timings.Wait = harMillis(t.Wait)
+}
+
+func harMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// harTruncated returns the comment of a body truncated to captured of size bytes.
+func harTruncated(captured int, size int64) string {
+	if int64(captured) == size {
+		return ""
+	}
+	return fmt.Sprintf("truncated to %d of %d bytes", captured, size)
+}
+
+func harText(b []byte) (text, encoding string) {
+	if utf8.Valid(b) {
+		return string(b), ""
+	}
+	return base64.StdEncoding.EncodeToString(b), "base64"
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	ret := make([]HARNameValue, 0, len(header))
+	for _, k := range sortedHeaderKeys(header) {
+		for _, v := range header[k] {
+			ret = append(ret, HARNameValue{Name: k, Value: v})
+		}
+	}
+	return ret
+}
+
+func harValues(values urlpkg.Values) []HARNameValue {
+	return harHeaders(http.Header(values))
+}
+
+func harCookies(cookies []*http.Cookie) []HARCookie {
+	ret := make([]HARCookie, 0, len(cookies))
+	for _, c := range cookies {
+		hc := HARCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		ret = append(ret, hc)
+	}
+	return ret
+}
+
+func harPostData(contentType string, body []byte, maxBodySize int) *HARPostData {
+	pd := &HARPostData{MimeType: contentType, Params: []HARNameValue{}}
+	if maxBodySize > 0 && len(body) > maxBodySize {
+		pd.Text, pd.Encoding = harText(body[:maxBodySize])
+		pd.Comment = harTruncated(maxBodySize, int64(len(body)))
+		return pd
+	}
+	pd.Text, pd.Encoding = harText(body)
+	if mediaType, _, _ := mime.ParseMediaType(contentType); strings.EqualFold(mediaType, "application/x-www-form-urlencoded") {
+		if values, err := urlpkg.ParseQuery(string(body)); err == nil {
+			pd.Params = harValues(values)
+		}
+	}
+	return pd
+}
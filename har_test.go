@@ -0,0 +1,122 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	urlpkg "net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestXClient_WithHARRecorder(t *testing.T) {
+	rec := NewHARRecorder()
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		WithHARRecorder(rec).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+			w.Header().Set("Content-Type", ContentTypeValueJSON)
+			body, _ := io.ReadAll(r.Body)
+			io.WriteString(w, `{"echo":"`+string(body)+`"}`)
+		}))
+
+	var successV map[string]string
+	formData := urlpkg.Values{"hello": []string{"world"}}
+	if _, _, err := cli.DoOnceWithBodyCodec(BodyCodecFormUrlencodedAndJSON, &successV, nil,
+		NewPost().Path("form").SetQuery("q", "1").Body(formData)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp, cancel, err := cli.DoWithRaw(NewGet().Path("raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.HAR().Log.Entries[1].Response.Content.Text) != 0 {
+		t.Fatal("entry completed before the body is read")
+	}
+	io.ReadAll(resp.Body)
+	cancel()
+
+	name := filepath.Join(t.TempDir(), "client.har")
+	if err = rec.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	h, err := ReadHARFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if h.Log.Version != "1.2" || len(h.Log.Entries) != 2 {
+		t.Fatalf("log = %+v", h.Log)
+	}
+	post, get := h.Log.Entries[0], h.Log.Entries[1]
+
+	if post.Request.Method != http.MethodPost || post.Request.URL != "https://api.example.com/form?q=1" {
+		t.Fatalf("request = %s %s", post.Request.Method, post.Request.URL)
+	}
+	if qs := post.Request.QueryString; len(qs) != 1 || qs[0] != (HARNameValue{Name: "q", Value: "1"}) {
+		t.Fatalf("queryString = %+v", qs)
+	}
+	if pd := post.Request.PostData; pd == nil || pd.Text != "hello=world" || pd.MimeType != ContentTypeValueFormUrlencoded ||
+		len(pd.Params) != 1 || pd.Params[0] != (HARNameValue{Name: "hello", Value: "world"}) {
+		t.Fatalf("postData = %+v", pd)
+	}
+	if post.Request.BodySize != len("hello=world") {
+		t.Fatalf("request.bodySize = %d", post.Request.BodySize)
+	}
+
+	if post.Response.Status != http.StatusOK || post.Response.Content.Text != `{"echo":"hello=world"}` ||
+		post.Response.Content.MimeType != ContentTypeValueJSON {
+		t.Fatalf("response = %+v", post.Response)
+	}
+	if c := post.Response.Cookies; len(c) != 1 || c[0].Name != "session" || c[0].Value != "s1" {
+		t.Fatalf("response.cookies = %+v", c)
+	}
+	if post.Timings.Wait < 0 || post.Timings.Receive < 0 || post.Timings.DNS != -1 {
+		t.Fatalf("timings = %+v", post.Timings)
+	}
+
+	if get.Request.PostData != nil || get.Response.Content.Text != `{"echo":""}` {
+		t.Fatalf("entry = %+v", get)
+	}
+}
+
+func TestHARRecorder_WithMaxBodySize(t *testing.T) {
+	rec := NewHARRecorder().WithMaxBodySize(4)
+	cli := NewClient().WithHARRecorder(rec).WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+
+	_, resp, cancel, err := cli.DoWithRaw(NewPost().Path("https://example.com").Body("0123456789"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	cancel()
+
+	e := rec.HAR().Log.Entries[0]
+	if pd := e.Request.PostData; e.Request.BodySize != 13 || pd.Text != `"012` || pd.Comment != "truncated to 4 of 13 bytes" {
+		t.Fatalf("request = %+v, postData = %+v", e.Request, pd)
+	}
+	if pd := e.Request.PostData; pd.Encoding != "" {
+		t.Fatalf("postData = %+v, want text", pd)
+	}
+	if c := e.Response.Content; e.Response.BodySize != 13 || c.Size != 13 || c.Text != `"012` || c.Comment != "truncated to 4 of 13 bytes" {
+		t.Fatalf("response = %+v", e.Response)
+	}
+}
+
+func TestHarPostData_binary(t *testing.T) {
+	binary := []byte{0xff, 0xfe, 0, 1}
+	for _, limit := range []int{0, 2} {
+		pd := harPostData("application/octet-stream", binary, limit)
+		want := binary
+		if limit > 0 {
+			want = binary[:limit]
+		}
+		if pd.Encoding != "base64" || !bytes.Equal(pd.Bytes(), want) {
+			t.Fatalf("limit %d: postData = %+v", limit, pd)
+		}
+	}
+}
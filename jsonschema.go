@@ -0,0 +1,640 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema is a compiled JSON Schema, see CompileJSONSchema.
+type JSONSchema struct {
+	root *schemaNode
+}
+
+// CompileJSONSchema compiles the JSON Schema doc with the validation keywords:
+//
+//   - $ref to a JSON pointer in doc, e.g. "#/$defs/pet", a recursive reference must be nested in properties or items
+//   - type, enum, const, and nullable of OpenAPI 3.0
+//   - properties, required, additionalProperties, minProperties, maxProperties
+//   - items, minItems, maxItems, uniqueItems
+//   - minLength, maxLength, pattern
+//   - minimum, maximum, exclusiveMinimum, exclusiveMaximum (a number, or a boolean of OpenAPI 3.0), multipleOf
+//   - allOf, anyOf, oneOf, not
+//
+// The other keywords are ignored, e.g. format is an annotation.
+func CompileJSONSchema(doc []byte) (*JSONSchema, error) {
+	v, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	c := newSchemaCompiler(v)
+	root, err := c.compile(v, "#")
+	if err != nil {
+		return nil, err
+	}
+	if err = c.checkCycles(); err != nil {
+		return nil, err
+	}
+	return &JSONSchema{root: root}, nil
+}
+
+// Validate validates the JSON document data, the violations are reported by *ValidationError.
+func (s *JSONSchema) Validate(data []byte) error {
+	v, err := decodeJSONValue(data)
+	if err != nil {
+		return err
+	}
+	var vs []Violation
+	s.root.validate(v, "", &vs)
+	if len(vs) != 0 {
+		return &ValidationError{Violations: vs}
+	}
+	return nil
+}
+
+// Violation is a value that does not satisfy a schema keyword.
+type Violation struct {
+	// Pointer is the JSON pointer (RFC 6901) of the value, "" is the document.
+	Pointer string
+	// Keyword is the schema keyword, e.g. "type" or "required".
+	Keyword string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%q: %s", v.Pointer, v.Message)
+}
+
+// ValidationError is returned if a body does not satisfy its schema.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("validation failed: ")
+	for i, v := range e.Violations {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+func decodeJSONValue(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return v, nil
+}
+
+type schemaNode struct {
+	boolean *bool
+	ref     *schemaNode
+
+	types    []string
+	nullable bool
+	enum     []any
+	constant *any
+
+	properties    map[string]*schemaNode
+	required      []string
+	additional    *schemaNode
+	minProperties *int
+	maxProperties *int
+
+	items       *schemaNode
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*schemaNode
+	anyOf []*schemaNode
+	oneOf []*schemaNode
+	not   *schemaNode
+}
+
+type schemaCompiler struct {
+	doc   any
+	nodes map[string]*schemaNode // by the JSON pointer in doc
+}
+
+func newSchemaCompiler(doc any) *schemaCompiler {
+	return &schemaCompiler{doc: doc, nodes: make(map[string]*schemaNode)}
+}
+
+// checkCycles rejects the schemas applied to the same value by themselves, e.g. {"$ref": "#"},
+// whose validation would never end.
+func (c *schemaCompiler) checkCycles() error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[*schemaNode]int, len(c.nodes))
+	// visit returns the node closing a cycle
+	var visit func(n *schemaNode) *schemaNode
+	visit = func(n *schemaNode) *schemaNode {
+		switch state[n] {
+		case visiting:
+			return n
+		case visited:
+			return nil
+		}
+		state[n] = visiting
+		for _, next := range append(append(append([]*schemaNode{n.ref, n.not}, n.allOf...), n.anyOf...), n.oneOf...) {
+			if next == nil {
+				continue
+			}
+			if cyclic := visit(next); cyclic != nil {
+				return cyclic
+			}
+		}
+		state[n] = visited
+		return nil
+	}
+	ptrs := sortedMapKeys(c.nodes)
+	for _, ptr := range ptrs {
+		cyclic := visit(c.nodes[ptr])
+		if cyclic == nil {
+			continue
+		}
+		for _, ptr := range ptrs {
+			if c.nodes[ptr] == cyclic {
+				return fmt.Errorf("schema %s: $ref cycle without a nested value", ptr)
+			}
+		}
+	}
+	return nil
+}
+
+// compile compiles v located at ptr of the document, the nodes are shared by the references.
+func (c *schemaCompiler) compile(v any, ptr string) (*schemaNode, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+	n := new(schemaNode)
+	c.nodes[ptr] = n
+
+	if b, ok := v.(bool); ok {
+		n.boolean = &b
+		return n, nil
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema %s: must be an object or a boolean", ptr)
+	}
+
+	var err error
+	sub := func(key string) (*schemaNode, error) {
+		if v, ok := m[key]; ok {
+			return c.compile(v, ptr+"/"+escapeJSONPointer(key))
+		}
+		return nil, nil
+	}
+	subs := func(key string) ([]*schemaNode, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		vs, ok := v.([]any)
+		if !ok {
+			return nil, fmt.Errorf("schema %s: %s must be an array", ptr, key)
+		}
+		nodes := make([]*schemaNode, len(vs))
+		for i, v := range vs {
+			if nodes[i], err = c.compile(v, ptr+"/"+key+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+	number := func(key string) (*float64, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		num, ok := v.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("schema %s: %s must be a number", ptr, key)
+		}
+		f, err := num.Float64()
+		return &f, err
+	}
+	integer := func(key string) (*int, error) {
+		f, err := number(key)
+		if f == nil || err != nil {
+			return nil, err
+		}
+		i := int(*f)
+		return &i, nil
+	}
+
+	if ref, ok := m["$ref"].(string); ok {
+		target, err := lookupJSONPointer(c.doc, ref)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", ptr, err)
+		}
+		if n.ref, err = c.compile(target, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	switch t := m["type"].(type) {
+	case string:
+		n.types = []string{t}
+	case []any:
+		for _, t := range t {
+			if s, ok := t.(string); ok {
+				n.types = append(n.types, s)
+			}
+		}
+	}
+	n.nullable, _ = m["nullable"].(bool)
+	if enum, ok := m["enum"].([]any); ok {
+		n.enum = enum
+	}
+	if constant, ok := m["const"]; ok {
+		n.constant = &constant
+	}
+
+	if props, ok := m["properties"].(map[string]any); ok {
+		n.properties = make(map[string]*schemaNode, len(props))
+		for k, v := range props {
+			if n.properties[k], err = c.compile(v, ptr+"/properties/"+escapeJSONPointer(k)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if required, ok := m["required"].([]any); ok {
+		for _, r := range required {
+			if s, ok := r.(string); ok {
+				n.required = append(n.required, s)
+			}
+		}
+	}
+	if n.additional, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if n.minProperties, err = integer("minProperties"); err != nil {
+		return nil, err
+	}
+	if n.maxProperties, err = integer("maxProperties"); err != nil {
+		return nil, err
+	}
+
+	if n.items, err = sub("items"); err != nil {
+		return nil, err
+	}
+	if n.minItems, err = integer("minItems"); err != nil {
+		return nil, err
+	}
+	if n.maxItems, err = integer("maxItems"); err != nil {
+		return nil, err
+	}
+	n.uniqueItems, _ = m["uniqueItems"].(bool)
+
+	if n.minLength, err = integer("minLength"); err != nil {
+		return nil, err
+	}
+	if n.maxLength, err = integer("maxLength"); err != nil {
+		return nil, err
+	}
+	if pattern, ok := m["pattern"].(string); ok {
+		if n.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("schema %s: pattern: %w", ptr, err)
+		}
+	}
+
+	if n.minimum, err = number("minimum"); err != nil {
+		return nil, err
+	}
+	if n.maximum, err = number("maximum"); err != nil {
+		return nil, err
+	}
+	// OpenAPI 3.0 declares the exclusive bounds as booleans
+	if exclusive, ok := m["exclusiveMinimum"].(bool); ok {
+		if exclusive {
+			n.exclusiveMinimum, n.minimum = n.minimum, nil
+		}
+	} else if n.exclusiveMinimum, err = number("exclusiveMinimum"); err != nil {
+		return nil, err
+	}
+	if exclusive, ok := m["exclusiveMaximum"].(bool); ok {
+		if exclusive {
+			n.exclusiveMaximum, n.maximum = n.maximum, nil
+		}
+	} else if n.exclusiveMaximum, err = number("exclusiveMaximum"); err != nil {
+		return nil, err
+	}
+	if n.multipleOf, err = number("multipleOf"); err != nil {
+		return nil, err
+	}
+
+	if n.allOf, err = subs("allOf"); err != nil {
+		return nil, err
+	}
+	if n.anyOf, err = subs("anyOf"); err != nil {
+		return nil, err
+	}
+	if n.oneOf, err = subs("oneOf"); err != nil {
+		return nil, err
+	}
+	if n.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (n *schemaNode) validate(v any, ptr string, vs *[]Violation) {
+	report := func(keyword, format string, args ...any) {
+		*vs = append(*vs, Violation{Pointer: ptr, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n.boolean != nil {
+		if !*n.boolean {
+			report("false", "no value is allowed")
+		}
+		return
+	}
+	if n.ref != nil {
+		n.ref.validate(v, ptr, vs)
+	}
+	if v == nil && n.nullable {
+		return
+	}
+
+	if len(n.types) > 0 && !matchJSONTypes(v, n.types) {
+		report("type", "expected %s, got %s", strings.Join(n.types, " or "), jsonTypeOf(v))
+		return
+	}
+	if n.enum != nil && !containsJSONValue(n.enum, v) {
+		report("enum", "must be one of %s", marshalJSONValue(n.enum))
+	}
+	if n.constant != nil && !equalJSONValues(*n.constant, v) {
+		report("const", "must be %s", marshalJSONValue(*n.constant))
+	}
+
+	switch tv := v.(type) {
+	case string:
+		length := utf8.RuneCountInString(tv)
+		if n.minLength != nil && length < *n.minLength {
+			report("minLength", "length %d is less than %d", length, *n.minLength)
+		}
+		if n.maxLength != nil && length > *n.maxLength {
+			report("maxLength", "length %d is greater than %d", length, *n.maxLength)
+		}
+		if n.pattern != nil && !n.pattern.MatchString(tv) {
+			report("pattern", "does not match %q", n.pattern)
+		}
+	case json.Number:
+		f, _ := tv.Float64()
+		if n.minimum != nil && f < *n.minimum {
+			report("minimum", "%s is less than %v", tv, *n.minimum)
+		}
+		if n.maximum != nil && f > *n.maximum {
+			report("maximum", "%s is greater than %v", tv, *n.maximum)
+		}
+		if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+			report("exclusiveMinimum", "%s is not greater than %v", tv, *n.exclusiveMinimum)
+		}
+		if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+			report("exclusiveMaximum", "%s is not less than %v", tv, *n.exclusiveMaximum)
+		}
+		if n.multipleOf != nil && *n.multipleOf > 0 {
+			if q := f / *n.multipleOf; math.Abs(q-math.Round(q)) > 1e-9 {
+				report("multipleOf", "%s is not a multiple of %v", tv, *n.multipleOf)
+			}
+		}
+	case map[string]any:
+		for _, k := range n.required {
+			if _, ok := tv[k]; !ok {
+				report("required", "missing property %q", k)
+			}
+		}
+		if n.minProperties != nil && len(tv) < *n.minProperties {
+			report("minProperties", "has %d properties, less than %d", len(tv), *n.minProperties)
+		}
+		if n.maxProperties != nil && len(tv) > *n.maxProperties {
+			report("maxProperties", "has %d properties, more than %d", len(tv), *n.maxProperties)
+		}
+		keys := make([]string, 0, len(tv))
+		for k := range tv {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := ptr + "/" + escapeJSONPointer(k)
+			if prop, ok := n.properties[k]; ok {
+				prop.validate(tv[k], child, vs)
+				continue
+			}
+			if n.additional == nil {
+				continue
+			}
+			if n.additional.boolean != nil && !*n.additional.boolean {
+				*vs = append(*vs, Violation{Pointer: child, Keyword: "additionalProperties", Message: "property is not allowed"})
+				continue
+			}
+			n.additional.validate(tv[k], child, vs)
+		}
+	case []any:
+		if n.minItems != nil && len(tv) < *n.minItems {
+			report("minItems", "has %d items, less than %d", len(tv), *n.minItems)
+		}
+		if n.maxItems != nil && len(tv) > *n.maxItems {
+			report("maxItems", "has %d items, more than %d", len(tv), *n.maxItems)
+		}
+		if n.uniqueItems {
+		unique:
+			for i := range tv {
+				for j := i + 1; j < len(tv); j++ {
+					if equalJSONValues(tv[i], tv[j]) {
+						report("uniqueItems", "items %d and %d are equal", i, j)
+						break unique
+					}
+				}
+			}
+		}
+		if n.items != nil {
+			for i, item := range tv {
+				n.items.validate(item, ptr+"/"+strconv.Itoa(i), vs)
+			}
+		}
+	}
+
+	for _, sub := range n.allOf {
+		sub.validate(v, ptr, vs)
+	}
+	if len(n.anyOf) > 0 && countMatches(n.anyOf, v, ptr) == 0 {
+		report("anyOf", "does not match any schema of anyOf")
+	}
+	if len(n.oneOf) > 0 {
+		if matched := countMatches(n.oneOf, v, ptr); matched != 1 {
+			report("oneOf", "matches %d schemas of oneOf, expected exactly 1", matched)
+		}
+	}
+	if n.not != nil && countMatches([]*schemaNode{n.not}, v, ptr) == 1 {
+		report("not", "must not match the schema of not")
+	}
+}
+
+func countMatches(nodes []*schemaNode, v any, ptr string) int {
+	matched := 0
+	for _, n := range nodes {
+		var vs []Violation
+		if n.validate(v, ptr, &vs); len(vs) == 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+func jsonTypeOf(v any) string {
+	switch tv := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if isJSONInteger(tv) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func matchJSONTypes(v any, types []string) bool {
+	actual := jsonTypeOf(v)
+	for _, t := range types {
+		if t == actual || t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func isJSONInteger(n json.Number) bool {
+	if _, err := n.Int64(); err == nil {
+		return true
+	}
+	f, err := n.Float64()
+	return err == nil && f == math.Trunc(f) && !math.IsInf(f, 0)
+}
+
+func containsJSONValue(values []any, v any) bool {
+	for _, e := range values {
+		if equalJSONValues(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalJSONValues(a, b any) bool {
+	switch ta := a.(type) {
+	case json.Number:
+		tb, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fa, _ := ta.Float64()
+		fb, _ := tb.Float64()
+		return fa == fb
+	case []any:
+		tb, ok := b.([]any)
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for i := range ta {
+			if !equalJSONValues(ta[i], tb[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		tb, ok := b.(map[string]any)
+		if !ok || len(ta) != len(tb) {
+			return false
+		}
+		for k, v := range ta {
+			if w, ok := tb[k]; !ok || !equalJSONValues(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+func marshalJSONValue(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func escapeJSONPointer(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// lookupJSONPointer returns the value of the fragment ref in doc, e.g. "#/components/schemas/Pet".
+func lookupJSONPointer(doc any, ref string) (any, error) {
+	ptr, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %q, only local references are supported", ref)
+	}
+	v := doc
+	if ptr == "" {
+		return v, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("unresolved $ref %q", ref)
+	}
+	for _, token := range strings.Split(ptr[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch tv := v.(type) {
+		case map[string]any:
+			if v, ok = tv[token]; !ok {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(tv) {
+				return nil, fmt.Errorf("unresolved $ref %q", ref)
+			}
+			v = tv[i]
+		default:
+			return nil, fmt.Errorf("unresolved $ref %q", ref)
+		}
+	}
+	return v, nil
+}
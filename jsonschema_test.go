@@ -0,0 +1,119 @@
+package xhttpclient
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONSchema_Validate(t *testing.T) {
+	schema, err := CompileJSONSchema([]byte(`{
+		"$defs": {
+			"tag": {"type": "string", "minLength": 1, "maxLength": 3, "pattern": "^[a-z]+$"}
+		},
+		"type": "object",
+		"required": ["id", "name"],
+		"additionalProperties": false,
+		"properties": {
+			"id": {"type": "integer", "minimum": 1},
+			"name": {"type": "string"},
+			"price": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01},
+			"status": {"enum": ["available", "sold"]},
+			"kind": {"const": "pet"},
+			"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "maxItems": 2, "uniqueItems": true},
+			"owner": {"type": ["object", "null"], "properties": {"a/b": {"type": "boolean"}}},
+			"legacy": {"type": "string", "nullable": true},
+			"score": {"type": "number", "minimum": 0, "maximum": 10, "exclusiveMaximum": true},
+			"contact": {
+				"oneOf": [
+					{"type": "object", "required": ["email"]},
+					{"type": "object", "required": ["phone"]}
+				]
+			},
+			"alias": {"anyOf": [{"type": "string"}, {"type": "integer"}], "not": {"const": ""}},
+			"extra": {"type": "object", "minProperties": 1, "additionalProperties": {"type": "integer"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = schema.Validate([]byte(`{
+		"id": 1, "name": "a", "price": 9.99, "status": "sold", "kind": "pet", "tags": ["x", "y"],
+		"owner": null, "legacy": null, "score": 9.5, "contact": {"email": "e"}, "alias": 2, "extra": {"n": 1}
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	err = schema.Validate([]byte(`{
+		"id": 0, "price": 0.001, "status": "lost", "kind": "toy", "tags": ["ab", "ab", "Abcd"],
+		"owner": {"a/b": 1}, "legacy": 1, "score": 10, "contact": {"email": "e", "phone": "p"},
+		"alias": "", "extra": {"n": 1.5}, "unknown": true
+	}`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("err = %v", err)
+	}
+	got := make([]string, len(ve.Violations))
+	for i, v := range ve.Violations {
+		got[i] = v.Pointer + " " + v.Keyword
+	}
+	want := []string{
+		" required",
+		"/alias not",
+		"/contact oneOf",
+		"/extra/n type",
+		"/id minimum",
+		"/kind const",
+		"/legacy type",
+		"/owner/a~1b type",
+		"/price multipleOf",
+		"/score exclusiveMaximum",
+		"/status enum",
+		"/tags maxItems",
+		"/tags uniqueItems",
+		"/tags/2 maxLength",
+		"/tags/2 pattern",
+		"/unknown additionalProperties",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("violations:\n%s\nwant:\n%s\n%v", strings.Join(got, "\n"), strings.Join(want, "\n"), err)
+	}
+	if !strings.Contains(err.Error(), `"": missing property "name"`) {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestCompileJSONSchema_errors(t *testing.T) {
+	for doc, wantErr := range map[string]string{
+		`{"$ref": "#/$defs/missing"}`:          `unresolved $ref "#/$defs/missing"`,
+		`{"$ref": "other.json#/a"}`:            "only local references are supported",
+		`{"pattern": "("}`:                     "pattern",
+		`{"properties": {"a": 1}}`:             "schema #/properties/a: must be an object or a boolean",
+		`{"allOf": {}}`:                        "allOf must be an array",
+		`{"minimum": "1"}`:                     "minimum must be a number",
+		`{"type": "object"} {"type": "array"}`: "invalid character after top-level value",
+		`{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`: "schema #/$defs/a: $ref cycle",
+		`{"allOf": [{"not": {"$ref": "#"}}]}`:                          "$ref cycle",
+	} {
+		if _, err := CompileJSONSchema([]byte(doc)); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("CompileJSONSchema(%s) err = %v, want %q", doc, err, wantErr)
+		}
+	}
+}
+
+func TestJSONSchema_recursive(t *testing.T) {
+	schema, err := CompileJSONSchema([]byte(`{
+		"type": "object",
+		"properties": {"children": {"type": "array", "items": {"$ref": "#"}}, "name": {"type": "string"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.Validate([]byte(`{"children": [{"children": [{"name": 1}]}]}`))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || len(ve.Violations) != 1 || ve.Violations[0].Pointer != "/children/0/children/0/name" {
+		t.Fatalf("err = %v", err)
+	}
+}
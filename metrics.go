@@ -0,0 +1,101 @@
+package xhttpclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsLabels are the low-cardinality labels of a request.
+type MetricsLabels struct {
+	Method string
+	Host   string
+	// Route is set by XRequestBuilder.Route or XRequestBuilder.PathTemplate, e.g. "/users/{id}", empty if not set.
+	Route string
+	// StatusClass is "1xx" to "5xx", or "error" if the round trip failed, it is empty for InFlight.
+	StatusClass string
+}
+
+type RequestMetrics struct {
+	// StatusCode is 0 if the round trip failed.
+	StatusCode int
+	// Duration is from sending the request to the response body read to EOF or closed.
+	Duration time.Duration
+	// RequestSize is the encoded body size, -1 if unknown.
+	RequestSize int64
+	// ResponseSize is the number of body bytes read.
+	ResponseSize int64
+	Err          error
+}
+
+// MetricsRecorder is invoked from XClient.do for every request,
+// see the xhttpprom module for Prometheus.
+type MetricsRecorder interface {
+	// InFlight is called with 1 before the request is sent and -1 right before Observe.
+	InFlight(labels MetricsLabels, delta int)
+	// Observe is called once the response body is read to EOF or closed, or right after the round trip if it fails.
+	Observe(labels MetricsLabels, m RequestMetrics)
+}
+
+func (xc *XClient) WithMetricsRecorder(recorder MetricsRecorder) *XClient {
+	xc.metrics = recorder
+	return xc
+}
+
+// Route sets the route label of the metrics and tracing, it must be low-cardinality, the default is the PathTemplate.
+func (xr *XRequestBuilder) Route(name string) *XRequestBuilder {
+	xr.route = name
+	return xr
+}
+
+type metricsRoundTrip struct {
+	recorder MetricsRecorder
+	labels   MetricsLabels
+	start    time.Time
+	reqSize  int64
+}
+
+// beginMetrics is called right before the request is sent.
+func beginMetrics(recorder MetricsRecorder, req *http.Request, route string) *metricsRoundTrip {
+	rt := &metricsRoundTrip{
+		recorder: recorder,
+		labels:   MetricsLabels{Method: req.Method, Host: req.URL.Host, Route: route},
+		start:    time.Now(),
+		reqSize:  req.ContentLength,
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		rt.reqSize = 0
+	}
+	recorder.InFlight(rt.labels, 1)
+	return rt
+}
+
+// end is called once the response header is received, it observes once the body is read.
+func (rt *metricsRoundTrip) end(resp *http.Response, err error) {
+	if err != nil {
+		rt.observe(0, 0, err)
+		return
+	}
+
+	body := &notifyBody{ReadCloser: resp.Body}
+	body.finish = func() { rt.observe(resp.StatusCode, body.n, nil) }
+	resp.Body = body
+}
+
+func (rt *metricsRoundTrip) observe(statusCode int, respSize int64, err error) {
+	rt.recorder.InFlight(rt.labels, -1)
+
+	labels := rt.labels
+	if err != nil {
+		labels.StatusClass = "error"
+	} else {
+		labels.StatusClass = strconv.Itoa(statusCode/100) + "xx"
+	}
+	rt.recorder.Observe(labels, RequestMetrics{
+		StatusCode:   statusCode,
+		Duration:     time.Since(rt.start),
+		RequestSize:  rt.reqSize,
+		ResponseSize: respSize,
+		Err:          err,
+	})
+}
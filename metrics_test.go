@@ -0,0 +1,87 @@
+package xhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+type testMetricsRecorder struct {
+	mu       sync.Mutex
+	inFlight int
+	observed []RequestMetrics
+	labels   []MetricsLabels
+}
+
+func (r *testMetricsRecorder) InFlight(labels MetricsLabels, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight += delta
+}
+
+func (r *testMetricsRecorder) Observe(labels MetricsLabels, m RequestMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.labels = append(r.labels, labels)
+	r.observed = append(r.observed, m)
+}
+
+func TestXClient_WithMetricsRecorder(t *testing.T) {
+	rec := new(testMetricsRecorder)
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		WithMetricsRecorder(rec).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			io.WriteString(w, `{"ok":true}`)
+		}))
+
+	var successV map[string]any
+	if _, _, err := cli.Do(&successV, nil, NewPost().Path("users", "1").Route("/users/{id}").Body(map[string]int{"n": 1})); err != nil {
+		t.Fatal(err)
+	}
+	cli.Do(&successV, nil, NewGet().Path("missing"))
+
+	_, _, cancel, err := cli.DoWithRaw(NewGet().Path("raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.inFlight != 1 || len(rec.observed) != 2 {
+		t.Fatalf("inFlight = %d, observed = %d before the body is read", rec.inFlight, len(rec.observed))
+	}
+	cancel()
+
+	if rec.inFlight != 0 || len(rec.observed) != 3 {
+		t.Fatalf("inFlight = %d, observed = %d", rec.inFlight, len(rec.observed))
+	}
+
+	want := MetricsLabels{Method: http.MethodPost, Host: "api.example.com", Route: "/users/{id}", StatusClass: "2xx"}
+	if rec.labels[0] != want {
+		t.Fatalf("labels = %+v, want %+v", rec.labels[0], want)
+	}
+	if m := rec.observed[0]; m.StatusCode != http.StatusOK || m.RequestSize != int64(len("{\"n\":1}\n")) ||
+		m.ResponseSize != int64(len(`{"ok":true}`)) || m.Duration <= 0 || m.Err != nil {
+		t.Fatalf("metrics = %+v", m)
+	}
+	if rec.labels[1].StatusClass != "4xx" || rec.labels[1].Route != "" || rec.observed[1].RequestSize != 0 {
+		t.Fatalf("labels = %+v, metrics = %+v", rec.labels[1], rec.observed[1])
+	}
+	if rec.observed[2].ResponseSize != int64(len(`{"ok":true}`)) {
+		t.Fatalf("metrics = %+v", rec.observed[2])
+	}
+
+	// failed round trip
+	cli.WithClient(&http.Client{Transport: testRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("refused")
+	})})
+	if _, _, err = cli.Do(&successV, nil, NewGet().Path("down")); err == nil {
+		t.Fatal("want error")
+	}
+	if rec.inFlight != 0 || rec.labels[3].StatusClass != "error" || rec.observed[3].Err == nil {
+		t.Fatalf("inFlight = %d, labels = %+v, metrics = %+v", rec.inFlight, rec.labels[3], rec.observed[3])
+	}
+}
@@ -0,0 +1,100 @@
+package xhttpclient
+
+import "fmt"
+
+// DecodeBranch is the target the response body is routed to by DoOnceWithBodyCodec, see XRequestBuilder.MatchedBranch.
+type DecodeBranch int
+
+const (
+	// DecodeBranchNone is reported if no target is matched, e.g. 204 or an error before decoding.
+	DecodeBranchNone DecodeBranch = iota
+	// DecodeBranchSuccess is the successV.
+	DecodeBranchSuccess
+	// DecodeBranchWrong is the wrongV.
+	DecodeBranchWrong
+	// DecodeBranchStatus is a target of OnStatus.
+	DecodeBranchStatus
+	// DecodeBranchStatusClass is a target of OnStatusClass.
+	DecodeBranchStatusClass
+)
+
+func (b DecodeBranch) String() string {
+	switch b {
+	case DecodeBranchNone:
+		return "none"
+	case DecodeBranchSuccess:
+		return "success"
+	case DecodeBranchWrong:
+		return "wrong"
+	case DecodeBranchStatus:
+		return "status"
+	case DecodeBranchStatusClass:
+		return "status class"
+	}
+	return fmt.Sprintf("DecodeBranch(%d)", int(b))
+}
+
+type statusTargets struct {
+	codes   map[int]any
+	classes map[int]any
+	matched *DecodeBranch
+}
+
+// OnStatus decodes the response body of the status code into v instead of the successV or the wrongV,
+// e.g. OnStatus(http.StatusConflict, &conflict). Discard skips decoding.
+// The body of an unsuccessful status is decoded like the wrongV, see BodyCodecDecodeWrong.
+func (xr *XRequestBuilder) OnStatus(code int, v any) *XRequestBuilder {
+	if xr.statusTargets.codes == nil {
+		xr.statusTargets.codes = make(map[int]any)
+	}
+	xr.statusTargets.codes[code] = v
+	return xr
+}
+
+// OnStatusClass is OnStatus for the status class, e.g. 4 for the 4xx statuses, OnStatus takes precedence.
+func (xr *XRequestBuilder) OnStatusClass(class int, v any) *XRequestBuilder {
+	if xr.statusTargets.classes == nil {
+		xr.statusTargets.classes = make(map[int]any)
+	}
+	xr.statusTargets.classes[class] = v
+	return xr
+}
+
+// MatchedBranch stores the DecodeBranch of the response into b.
+func (xr *XRequestBuilder) MatchedBranch(b *DecodeBranch) *XRequestBuilder {
+	xr.statusTargets.matched = b
+	return xr
+}
+
+func (st statusTargets) clone() statusTargets {
+	c := statusTargets{matched: st.matched}
+	if st.codes != nil {
+		c.codes = make(map[int]any, len(st.codes))
+		for k, v := range st.codes {
+			c.codes[k] = v
+		}
+	}
+	if st.classes != nil {
+		c.classes = make(map[int]any, len(st.classes))
+		for k, v := range st.classes {
+			c.classes[k] = v
+		}
+	}
+	return c
+}
+
+func (st statusTargets) match(code int) (v any, branch DecodeBranch) {
+	if v, ok := st.codes[code]; ok {
+		return v, DecodeBranchStatus
+	}
+	if v, ok := st.classes[code/100]; ok {
+		return v, DecodeBranchStatusClass
+	}
+	return nil, DecodeBranchNone
+}
+
+func (st statusTargets) report(branch DecodeBranch) {
+	if st.matched != nil {
+		*st.matched = branch
+	}
+}
@@ -0,0 +1,77 @@
+package xhttpclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestXRequestBuilder_OnStatus(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		if code != http.StatusNoContent {
+			io.WriteString(w, `{"code":`+strconv.Itoa(code)+`}`)
+		}
+	}))
+
+	type body struct{ Code int }
+	tests := []struct {
+		code   int
+		branch DecodeBranch
+		target string
+	}{
+		{code: 200, branch: DecodeBranchSuccess, target: "success"},
+		{code: 202, branch: DecodeBranchStatus, target: "job"},
+		{code: 204, branch: DecodeBranchNone},
+		{code: 409, branch: DecodeBranchStatus, target: "conflict"},
+		{code: 422, branch: DecodeBranchStatusClass, target: "client"},
+		{code: 400, branch: DecodeBranchStatusClass, target: "client"},
+		{code: 500, branch: DecodeBranchWrong, target: "wrong"},
+		{code: 503, branch: DecodeBranchStatus},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.code), func(t *testing.T) {
+			var (
+				success, wrong, job, conflict, client body
+				branch                                = DecodeBranch(-1)
+			)
+			_, _, err := cli.Do(&success, &wrong, NewGet().
+				Path("http://example.com").
+				SetQuery("code", strconv.Itoa(tt.code)).
+				OnStatus(http.StatusAccepted, &job).
+				OnStatus(http.StatusConflict, &conflict).
+				OnStatus(http.StatusServiceUnavailable, Discard).
+				OnStatusClass(4, &client).
+				MatchedBranch(&branch),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if branch != tt.branch {
+				t.Fatalf("branch = %s, want %s", branch, tt.branch)
+			}
+			targets := map[string]body{"success": success, "wrong": wrong, "job": job, "conflict": conflict, "client": client}
+			for name, v := range targets {
+				if decoded := v.Code != 0; decoded != (name == tt.target) {
+					t.Fatalf("%s = %+v, want target %q", name, v, tt.target)
+				}
+			}
+		})
+	}
+}
+
+func TestXRequestBuilder_OnStatus_decodeError(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, "conflict")
+	}))
+
+	var conflict struct{}
+	branch := DecodeBranchNone
+	_, respBody, err := cli.Do(Discard, nil, NewGet().Path("http://example.com").OnStatus(http.StatusConflict, &conflict).MatchedBranch(&branch))
+	if err == nil || branch != DecodeBranchStatus || string(respBody) != "conflict" {
+		t.Fatalf("err = %v, branch = %s, body = %q", err, branch, respBody)
+	}
+}
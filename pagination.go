@@ -0,0 +1,299 @@
+package xhttpclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page is a decoded page of a Paginator.
+type Page[T any] struct {
+	// Index is the zero-based index of the page.
+	Index int
+	Value T
+	// Response is the response of the page, its body is already read into Body.
+	Response *http.Response
+	Body     []byte
+}
+
+// PageStrategy prepares the requests of the pages.
+type PageStrategy[T any] interface {
+	// Next prepares xr for the page following prev, prev is nil for the first page.
+	// It reports false if prev is the last page.
+	Next(xr *XRequestBuilder, prev *Page[T]) bool
+}
+
+// Paginator walks the pages of an endpoint lazily, the requests are created by newReq and prepared by the PageStrategy.
+//
+//	p := NewPaginator(cli, func() *XRequestBuilder { return NewGet().Path("repos") }, LinkPaging[[]Repo]{})
+//	defer p.Close()
+//	for p.Next(ctx) {
+//		repos := p.Page().Value
+//	}
+//	if err := p.Err(); err != nil {
+//		// ...
+//	}
+//
+// The pages are decoded by XClient.Do without a wrongV, so the wrong responses end the pagination with an error.
+// A Paginator is not safe for concurrent use.
+type Paginator[T any] struct {
+	xc       *XClient
+	newReq   func() *XRequestBuilder
+	strategy PageStrategy[T]
+	prefetch bool
+
+	page    *Page[T]
+	pending chan pageResult[T]
+	cancel  context.CancelFunc
+	done    bool
+	err     error
+}
+
+type pageResult[T any] struct {
+	page *Page[T]
+	done bool
+	err  error
+}
+
+// NewPaginator returns a Paginator of the pages decoded into T.
+func NewPaginator[T any](xc *XClient, newReq func() *XRequestBuilder, strategy PageStrategy[T]) *Paginator[T] {
+	return &Paginator[T]{xc: xc, newReq: newReq, strategy: strategy}
+}
+
+// Prefetch fetches the next page concurrently once a page is yielded,
+// with the context of the Next call yielding it.
+func (p *Paginator[T]) Prefetch() *Paginator[T] {
+	p.prefetch = true
+	return p
+}
+
+// Next fetches the next page, it reports false at the end of the pages or on an error, see Err.
+// The request of the page is sent with ctx.
+func (p *Paginator[T]) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+
+	var r pageResult[T]
+	if r.err = ctx.Err(); r.err == nil {
+		if p.pending != nil {
+			select {
+			case r = <-p.pending:
+			case <-ctx.Done():
+				r.err = ctx.Err()
+			}
+		} else {
+			r = p.fetch(ctx, p.page)
+		}
+	}
+	if p.pending != nil {
+		p.pending = nil
+		p.cancel()
+	}
+
+	switch {
+	case r.err != nil:
+		p.err = r.err
+		return false
+	case r.done:
+		p.done = true
+		return false
+	}
+
+	p.page = r.page
+	if p.prefetch {
+		var pctx context.Context
+		pctx, p.cancel = context.WithCancel(ctx)
+		p.pending = make(chan pageResult[T], 1)
+		go func(pending chan<- pageResult[T], prev *Page[T]) {
+			pending <- p.fetch(pctx, prev)
+		}(p.pending, p.page)
+	}
+	return true
+}
+
+// Page is the page yielded by Next.
+func (p *Paginator[T]) Page() *Page[T] {
+	return p.page
+}
+
+// Err is the error ending the pagination.
+func (p *Paginator[T]) Err() error {
+	return p.err
+}
+
+// Close cancels the prefetching, Next reports false after Close.
+func (p *Paginator[T]) Close() {
+	p.done = true
+	if p.pending != nil {
+		p.cancel()
+		p.pending = nil
+	}
+}
+
+// fetch must not modify p since it is called by the prefetching.
+func (p *Paginator[T]) fetch(ctx context.Context, prev *Page[T]) pageResult[T] {
+	xr := p.newReq()
+	if !p.strategy.Next(xr, prev) {
+		xr.free()
+		return pageResult[T]{done: true}
+	}
+
+	page := new(Page[T])
+	if prev != nil {
+		page.Index = prev.Index + 1
+	}
+	var err error
+	if page.Response, page.Body, err = p.xc.Do(&page.Value, nil, xr.WithContext(ctx)); err != nil {
+		return pageResult[T]{err: err}
+	}
+	return pageResult[T]{page: page}
+}
+
+// LinkPaging follows the RFC 8288 'Link' header with rel="next", the pagination ends without it.
+// The URL of the link replaces the path and the query of the request.
+type LinkPaging[T any] struct{}
+
+func (LinkPaging[T]) Next(xr *XRequestBuilder, prev *Page[T]) bool {
+	if prev == nil {
+		return true
+	}
+	next := nextLink(prev.Response.Header.Values("Link"))
+	if next == "" {
+		return false
+	}
+	if u, err := prev.Response.Request.URL.Parse(next); err == nil {
+		next = u.String()
+	}
+	xr.Path(next).Query(nil).QueryStruct(nil)
+	return true
+}
+
+// CursorPaging sets the query parameter Param to the cursor of the previous page,
+// the pagination ends with an empty cursor.
+type CursorPaging[T any] struct {
+	Param string
+	// Cursor returns the cursor of the next page from a decoded page.
+	Cursor func(page T) string
+}
+
+func (s CursorPaging[T]) Next(xr *XRequestBuilder, prev *Page[T]) bool {
+	if prev == nil {
+		return true
+	}
+	cursor := s.Cursor(prev.Value)
+	if cursor == "" {
+		return false
+	}
+	xr.SetQuery(s.Param, cursor)
+	return true
+}
+
+// OffsetPaging sets the query parameters OffsetParam and LimitParam,
+// the pagination ends with a page of less than Limit items.
+type OffsetPaging[T any] struct {
+	OffsetParam string
+	LimitParam  string
+	Limit       int
+	// Count returns the number of items of a decoded page.
+	Count func(page T) int
+}
+
+func (s OffsetPaging[T]) Next(xr *XRequestBuilder, prev *Page[T]) bool {
+	offset := 0
+	if prev != nil {
+		count := s.Count(prev.Value)
+		if count == 0 || count < s.Limit {
+			return false
+		}
+		offset, _ = strconv.Atoi(prev.Response.Request.URL.Query().Get(s.OffsetParam))
+		offset += count
+	}
+	xr.SetQuery(s.OffsetParam, strconv.Itoa(offset))
+	if s.LimitParam != "" {
+		xr.SetQuery(s.LimitParam, strconv.Itoa(s.Limit))
+	}
+	return true
+}
+
+// PageNumberPaging sets the query parameters PageParam and SizeParam if it is not empty,
+// the pagination ends with an empty page or a page of less than Size items.
+type PageNumberPaging[T any] struct {
+	PageParam string
+	// First is the number of the first page, e.g. 1.
+	First     int
+	SizeParam string
+	Size      int
+	// Count returns the number of items of a decoded page.
+	Count func(page T) int
+}
+
+func (s PageNumberPaging[T]) Next(xr *XRequestBuilder, prev *Page[T]) bool {
+	number := s.First
+	if prev != nil {
+		count := s.Count(prev.Value)
+		if count == 0 || count < s.Size {
+			return false
+		}
+		number += prev.Index + 1
+	}
+	xr.SetQuery(s.PageParam, strconv.Itoa(number))
+	if s.SizeParam != "" {
+		xr.SetQuery(s.SizeParam, strconv.Itoa(s.Size))
+	}
+	return true
+}
+
+// nextLink returns the target of the link with the relation type "next" of the 'Link' header values.
+func nextLink(values []string) string {
+	for _, v := range values {
+		for v != "" {
+			v = strings.TrimLeft(v, " \t,")
+			if !strings.HasPrefix(v, "<") {
+				break
+			}
+			end := strings.IndexByte(v, '>')
+			if end < 0 {
+				break
+			}
+			target := v[1:end]
+			v = v[end+1:]
+
+			var params string
+			params, v = cutLinkParams(v)
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(value), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// cutLinkParams cuts the parameters of a link-value at the comma outside the quoted strings.
+func cutLinkParams(s string) (params, rest string) {
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quoted {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				return s[:i], s[i+1:]
+			}
+		}
+	}
+	return s, ""
+}
@@ -0,0 +1,158 @@
+package xhttpclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func paginate[T any](t *testing.T, p *Paginator[T]) []T {
+	t.Helper()
+	defer p.Close()
+	var pages []T
+	for p.Next(context.Background()) {
+		if p.Page().Index != len(pages) {
+			t.Fatalf("index = %d, want %d", p.Page().Index, len(pages))
+		}
+		pages = append(pages, p.Page().Value)
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return pages
+}
+
+// itemsHandler serves the items 0..total-1 by the query parameters offset and limit, with the 'Link' of the next page.
+func itemsHandler(total int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit == 0 {
+			limit = 2
+		}
+		items := []int{}
+		for i := offset; i < offset+limit && i < total; i++ {
+			items = append(items, i)
+		}
+		next := ""
+		if offset+limit < total {
+			next = strconv.Itoa(offset + limit)
+			w.Header().Add("Link", `<https://example.com/first>; rel="first", <https://example.com/items?limit=`+
+				strconv.Itoa(limit)+`&offset=`+next+`>; title="a, b"; rel="last next"`)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"items": items, "next": next})
+	})
+}
+
+type itemsPage struct {
+	Items []int
+	Next  string
+}
+
+func itemsCount(p itemsPage) int { return len(p.Items) }
+
+func TestPaginator(t *testing.T) {
+	newReq := func() *XRequestBuilder { return NewGet().Path("items").SetQuery("limit", "2") }
+	want := []itemsPage{{Items: []int{0, 1}, Next: "2"}, {Items: []int{2, 3}, Next: "4"}, {Items: []int{4}}}
+
+	tests := map[string]PageStrategy[itemsPage]{
+		"link":   LinkPaging[itemsPage]{},
+		"cursor": CursorPaging[itemsPage]{Param: "offset", Cursor: func(p itemsPage) string { return p.Next }},
+		"offset": OffsetPaging[itemsPage]{OffsetParam: "offset", LimitParam: "limit", Limit: 2, Count: itemsCount},
+	}
+	for name, strategy := range tests {
+		t.Run(name, func(t *testing.T) {
+			cli := NewClient().BaseURL("https://example.com").WithHandler(itemsHandler(5))
+			if got := paginate(t, NewPaginator(cli, newReq, strategy)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("pages = %+v, want %+v", got, want)
+			}
+			if got := paginate(t, NewPaginator(cli, newReq, strategy).Prefetch()); !reflect.DeepEqual(got, want) {
+				t.Fatalf("prefetched pages = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestPageNumberPaging(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if r.URL.Query().Get("per_page") != "2" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		items := map[int][]int{1: {1, 2}, 2: {3, 4}}[page]
+		json.NewEncoder(w).Encode(items)
+	}))
+
+	strategy := PageNumberPaging[[]int]{
+		PageParam: "page",
+		First:     1,
+		SizeParam: "per_page",
+		Size:      2,
+		Count:     func(items []int) int { return len(items) },
+	}
+	got := paginate(t, NewPaginator(cli, func() *XRequestBuilder { return NewGet().Path("https://example.com") }, strategy))
+	if want := [][]int{{1, 2}, {3, 4}, nil}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pages = %v, want %v", got, want)
+	}
+}
+
+func TestPaginator_errors(t *testing.T) {
+	var requests atomic.Int32
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"items":[0],"next":"1"}`)
+	}))
+	strategy := CursorPaging[itemsPage]{Param: "cursor", Cursor: func(p itemsPage) string { return p.Next }}
+	newReq := func() *XRequestBuilder { return NewGet().Path("https://example.com") }
+
+	p := NewPaginator(cli, newReq, strategy)
+	if !p.Next(context.Background()) || p.Next(context.Background()) || p.Err() == nil {
+		t.Fatalf("err = %v", p.Err())
+	}
+	if p.Next(context.Background()) {
+		t.Fatal("Next after an error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p = NewPaginator(cli, newReq, strategy).Prefetch()
+	if !p.Next(ctx) {
+		t.Fatal(p.Err())
+	}
+	cancel()
+	if p.Next(ctx) || !errors.Is(p.Err(), context.Canceled) {
+		t.Fatalf("err = %v", p.Err())
+	}
+
+	p = NewPaginator(cli, newReq, strategy).Prefetch()
+	if !p.Next(context.Background()) {
+		t.Fatal(p.Err())
+	}
+	p.Close()
+	if p.Next(context.Background()) || p.Err() != nil {
+		t.Fatalf("Next after Close, err = %v", p.Err())
+	}
+}
+
+func TestNextLink(t *testing.T) {
+	for header, want := range map[string]string{
+		`<https://a/2>; rel="next"`:                          "https://a/2",
+		`<https://a/1>; rel=prev, <https://a/3>; rel=NEXT`:   "https://a/3",
+		`<https://a/1>; title="x, rel=next"; rel="prev"`:     "",
+		`<https://a/1>; rel="prev", <https://a/9>; rel=last`: "",
+		`invalid`: "",
+	} {
+		if got := nextLink([]string{header}); got != want {
+			t.Errorf("nextLink(%s) = %q, want %q", header, got, want)
+		}
+	}
+}
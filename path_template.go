@@ -0,0 +1,94 @@
+package xhttpclient
+
+import (
+	"fmt"
+	urlpkg "net/url"
+	"sort"
+	"strings"
+)
+
+// PathTemplate sets the path like Path, with the named parameters in braces replaced by PathParam,
+// e.g. PathTemplate("/users/{id}/repos/{repo}").
+// The template is the default route label of the metrics and tracing, see Route.
+func (xr *XRequestBuilder) PathTemplate(template string) *XRequestBuilder {
+	xr.pathTemplate = strings.TrimSpace(template)
+	xr.pathElements = nil
+	return xr
+}
+
+// PathParam sets the value of a PathTemplate parameter, it is escaped as a single path segment.
+func (xr *XRequestBuilder) PathParam(key, value string) *XRequestBuilder {
+	if xr.pathParams == nil {
+		xr.pathParams = make(map[string]string)
+	}
+	xr.pathParams[key] = value
+	return xr
+}
+
+// routeLabel returns the route set by Route, or the path template.
+func (xr *XRequestBuilder) routeLabel() string {
+	if xr.route != "" {
+		return xr.route
+	}
+	return xr.pathTemplate
+}
+
+// expandPathTemplate replaces the parameters of template, every parameter must be used exactly as declared.
+func expandPathTemplate(template string, params map[string]string) (string, error) {
+	var (
+		sb   strings.Builder
+		used = make(map[string]bool, len(params))
+	)
+	for rest := template; rest != ""; {
+		i := strings.IndexAny(rest, "{}")
+		if i < 0 {
+			sb.WriteString(rest)
+			break
+		}
+		if rest[i] == '}' {
+			return "", fmt.Errorf("path template %q: unexpected '}'", template)
+		}
+		sb.WriteString(rest[:i])
+		rest = rest[i+1:]
+
+		j := strings.IndexAny(rest, "{}")
+		if j < 0 || rest[j] == '{' {
+			return "", fmt.Errorf("path template %q: unclosed '{'", template)
+		}
+		name := rest[:j]
+		rest = rest[j+1:]
+		if name == "" {
+			return "", fmt.Errorf("path template %q: empty param name", template)
+		}
+
+		value, ok := params[name]
+		switch {
+		case !ok:
+			return "", fmt.Errorf("path template %q: missing param '%s'", template, name)
+		case value == "":
+			return "", fmt.Errorf("path template %q: empty param '%s'", template, name)
+		}
+		used[name] = true
+		sb.WriteString(escapePathSegment(value))
+	}
+
+	if len(used) != len(params) {
+		var unknown []string
+		for k := range params {
+			if !used[k] {
+				unknown = append(unknown, k)
+			}
+		}
+		sort.Strings(unknown)
+		return "", fmt.Errorf("path template %q: unknown params '%s'", template, strings.Join(unknown, "', '"))
+	}
+	return sb.String(), nil
+}
+
+// escapePathSegment escapes s as a single segment, including the dot segments which would be resolved otherwise.
+func escapePathSegment(s string) string {
+	if s == "." || s == ".." {
+		return strings.Repeat("%2E", len(s))
+	}
+	return urlpkg.PathEscape(s)
+}
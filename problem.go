@@ -0,0 +1,120 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// ContentTypeProblemJSON is the media type of ProblemDetails.
+const ContentTypeProblemJSON = "application/problem+json"
+
+// ProblemDetails is the RFC 9457 problem details of an unsuccessful response.
+//
+// DoOnceWithBodyCodec decodes the unsuccessful responses of ContentTypeProblemJSON into *ProblemDetails
+// and returns an error wrapping it, regardless of the wrongV, which is still decoded if it is not nil:
+//
+//	var problem *ProblemDetails
+//	if errors.As(err, &problem) {
+//		log.Println(problem.Type, problem.Title, problem.Detail, problem.Instance)
+//	}
+type ProblemDetails struct {
+	// Type is "about:blank" if the member is absent.
+	Type     string
+	Title    string
+	Status   int
+	Detail   string
+	Instance string
+	// Extensions are the extension members, e.g. "balance" of {"type": "...", "balance": 30}.
+	Extensions map[string]json.RawMessage
+}
+
+func (p *ProblemDetails) Error() string {
+	s := p.Title
+	if s == "" {
+		s = p.Type
+	} else if p.Type != "" && p.Type != "about:blank" {
+		s += " (" + p.Type + ")"
+	}
+	if p.Detail != "" {
+		s += ": " + p.Detail
+	}
+	return s
+}
+
+// Extension decodes the extension member name into v, it reports false if the member is absent.
+func (p *ProblemDetails) Extension(name string, v any) (bool, error) {
+	raw, ok := p.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return true, fmt.Errorf("problem extension %q: %w", name, err)
+	}
+	return true, nil
+}
+
+// UnmarshalJSON ignores the standard members of an invalid type, as required by RFC 9457.
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	*p = ProblemDetails{Type: "about:blank"}
+	for name, raw := range members {
+		var dst any
+		switch name {
+		case "type":
+			dst = &p.Type
+		case "title":
+			dst = &p.Title
+		case "status":
+			dst = &p.Status
+		case "detail":
+			dst = &p.Detail
+		case "instance":
+			dst = &p.Instance
+		default:
+			if p.Extensions == nil {
+				p.Extensions = make(map[string]json.RawMessage)
+			}
+			p.Extensions[name] = raw
+			continue
+		}
+		_ = json.Unmarshal(raw, dst)
+	}
+	return nil
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for name, raw := range p.Extensions {
+		members[name] = raw
+	}
+	for name, v := range map[string]string{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if v != "" {
+			members[name] = v
+		}
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	return json.Marshal(members)
+}
+
+// decodeProblem returns the *ProblemDetails of an unsuccessful response, or nil.
+func decodeProblem(bc BodyCodec, resp *http.Response, respBody []byte) error {
+	if !isWrong(bc, resp) && isSuccessful(bc, resp) {
+		return nil
+	}
+	if mt, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err != nil || mt != ContentTypeProblemJSON {
+		return nil
+	}
+	p := new(ProblemDetails)
+	if err := json.NewDecoder(bytes.NewReader(respBody)).Decode(p); err != nil {
+		return nil
+	}
+	return p
+}
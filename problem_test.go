@@ -0,0 +1,109 @@
+package xhttpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestXClient_Do_ProblemDetails(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/credit":
+			w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{
+				"type": "https://example.com/probs/out-of-credit",
+				"title": "You do not have enough credit.",
+				"status": 403,
+				"detail": "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance": 30
+			}`)
+		case "/invalid":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"status": "400", "title": "Bad Request"}`)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			io.WriteString(w, `{"title": "Bad Request"}`)
+		default:
+			w.Header().Set("Content-Type", "application/problem+json")
+			io.WriteString(w, `{"title": "OK"}`)
+		}
+	}))
+
+	var problem *ProblemDetails
+	_, _, err := cli.Do(Discard, nil, NewGet().Path("http://example.com/credit"))
+	if !errors.As(err, &problem) {
+		t.Fatalf("err = %v, want *ProblemDetails", err)
+	}
+	if problem.Type != "https://example.com/probs/out-of-credit" || problem.Status != 403 ||
+		problem.Instance != "/account/12345/msgs/abc" || problem.Detail == "" {
+		t.Fatalf("problem = %+v", problem)
+	}
+	var balance int
+	if ok, err := problem.Extension("balance", &balance); !ok || err != nil || balance != 30 {
+		t.Fatalf("balance = %d, ok = %t, err = %v", balance, ok, err)
+	}
+	if ok, _ := problem.Extension("missing", &balance); ok {
+		t.Fatal("missing extension is reported")
+	}
+
+	var wrong struct{ Balance int }
+	_, _, err = cli.Do(Discard, &wrong, NewGet().Path("http://example.com/credit"))
+	if !errors.As(err, &problem) || wrong.Balance != 30 {
+		t.Fatalf("err = %v, wrong = %+v", err, wrong)
+	}
+
+	_, _, err = cli.Do(Discard, nil, NewGet().Path("http://example.com/invalid"))
+	if !errors.As(err, &problem) || problem.Status != 0 || problem.Type != "about:blank" || problem.Title != "Bad Request" {
+		t.Fatalf("err = %v, problem = %+v", err, problem)
+	}
+
+	_, _, err = cli.Do(Discard, nil, NewGet().Path("http://example.com/json"))
+	if err == nil || errors.As(err, &problem) {
+		t.Fatalf("err = %v", err)
+	}
+
+	if _, _, err = cli.Do(Discard, nil, NewGet().Path("http://example.com/ok")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestProblemDetails_Error(t *testing.T) {
+	for _, tt := range []struct {
+		problem ProblemDetails
+		want    string
+	}{
+		{ProblemDetails{Type: "about:blank", Title: "Not Found"}, "Not Found"},
+		{ProblemDetails{Type: "https://example.com/probs/x", Title: "X", Detail: "y"}, "X (https://example.com/probs/x): y"},
+		{ProblemDetails{Type: "https://example.com/probs/x"}, "https://example.com/probs/x"},
+	} {
+		if got := tt.problem.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestProblemDetails_MarshalJSON(t *testing.T) {
+	in := `{"balance":30,"status":403,"title":"t","type":"https://example.com/probs/x"}`
+	var p ProblemDetails
+	if err := json.Unmarshal([]byte(in), &p); err != nil {
+		t.Fatal(err)
+	}
+	out, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]any
+	_ = json.Unmarshal(out, &got)
+	_ = json.Unmarshal([]byte(in), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("MarshalJSON = %s, want %s", out, in)
+	}
+}
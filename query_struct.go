@@ -0,0 +1,69 @@
+package xhttpclient
+
+import (
+	"fmt"
+	urlpkg "net/url"
+	"reflect"
+	"strings"
+)
+
+// QueryStruct encodes the fields of the struct v as query parameters, tagged with `query:"name,opts"`:
+//
+//   - omitempty: skips the zero value and the empty slice
+//   - comma: joins a slice as 'ids=1,2', the default repeats the key as 'ids=1&ids=2'
+//   - brackets: repeats a slice as 'ids[]=1&ids[]=2'
+//   - unix, unixmilli: formats a time.Time as a Unix timestamp, otherwise with the `layout:"..."` tag or RFC 3339
+//
+// The untagged fields are skipped and the untagged embedded structs are flattened,
+// pointers are dereferenced (nil is skipped) and encoding.TextMarshaler is used if implemented.
+// The parameters set by SetQuery, AddQuery and Query take precedence.
+func (xr *XRequestBuilder) QueryStruct(v any) *XRequestBuilder {
+	xr.queryStruct = v
+	return xr
+}
+
+func encodeQueryStruct(v any) (urlpkg.Values, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	query := make(urlpkg.Values)
+	for _, f := range cachedStructFields(rv.Type(), "query") {
+		if err = addQueryField(query, rv, f); err != nil {
+			return nil, err
+		}
+	}
+	return query, nil
+}
+
+func addQueryField(query urlpkg.Values, rv reflect.Value, f structField) error {
+	fv, ok := fieldByIndex(rv, f.index)
+	if !ok || f.hasOpt("omitempty") && isEmptyValue(fv) {
+		return nil
+	}
+
+	values, err := formatValues(fv, f)
+	if err != nil {
+		return fmt.Errorf("field '%s': %w", f.name, err)
+	}
+	switch {
+	case len(values) == 0:
+	case f.hasOpt("comma"):
+		query.Add(f.name, strings.Join(values, ","))
+	case f.hasOpt("brackets"):
+		query[f.name+"[]"] = append(query[f.name+"[]"], values...)
+	default:
+		query[f.name] = append(query[f.name], values...)
+	}
+	return nil
+}
+
+// isEmptyValue reports whether v is the zero value, or an empty slice or map.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,117 @@
+package xhttpclient
+
+import (
+	"net"
+	urlpkg "net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testQueryPage struct {
+	Page    int `query:"page,omitempty"`
+	PerPage int `query:"per_page,omitempty"`
+}
+
+type testQueryFilter struct {
+	*testQueryPage
+	Q        string        `query:"q"`
+	IDs      []int         `query:"ids"`
+	Tags     []string      `query:"tags,comma"`
+	States   []string      `query:"state,brackets"`
+	Since    time.Time     `query:"since" layout:"2006-01-02"`
+	Until    time.Time     `query:"until,unix,omitempty"`
+	At       *time.Time    `query:"at"`
+	Timeout  time.Duration `query:"timeout"`
+	Untagged string
+	IP       net.IP   `query:"ip,omitempty"`
+	Archived *bool    `query:"archived"`
+	Score    *float64 `query:"score,omitempty"`
+	Ignored  string   `query:"-"`
+	internal string
+}
+
+func TestXRequestBuilder_QueryStruct(t *testing.T) {
+	archived := false
+	filter := testQueryFilter{
+		testQueryPage: &testQueryPage{Page: 2},
+		Q:             "a b",
+		IDs:           []int{1, 2},
+		Tags:          []string{"x", "y"},
+		States:        []string{"open", "closed"},
+		Since:         time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Timeout:       time.Minute,
+		Untagged:      "untagged",
+		IP:            net.ParseIP("127.0.0.1"),
+		Archived:      &archived,
+		Ignored:       "ignored",
+		internal:      "internal",
+	}
+
+	xReq := NewGet().
+		Path("search?q=raw&keep=1").
+		QueryStruct(&filter).
+		SetQuery("page", "3")
+	xReq.baseURL = "https://api.example.com"
+	u, err := xReq.processingURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := urlpkg.Values{
+		"keep":     {"1"},
+		"page":     {"3"},
+		"q":        {"a b"},
+		"ids":      {"1", "2"},
+		"tags":     {"x,y"},
+		"state[]":  {"open", "closed"},
+		"since":    {"2024-05-01"},
+		"timeout":  {"1m0s"},
+		"ip":       {"127.0.0.1"},
+		"archived": {"false"},
+	}
+	if got := u.Query(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("query = %v\nwant %v", got, want)
+	}
+
+	filter.Until = time.Unix(1700000000, 0)
+	filter.testQueryPage = nil
+	query, err := encodeQueryStruct(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("until") != "1700000000" || query.Has("page") {
+		t.Fatalf("query = %v", query)
+	}
+
+	for _, v := range []any{nil, "str", (*testQueryFilter)(nil), struct {
+		C chan int `query:"c"`
+	}{}} {
+		if _, err = encodeQueryStruct(v); err == nil {
+			t.Fatalf("encodeQueryStruct(%#v) = nil, want error", v)
+		}
+	}
+	xReq = NewGet().Path("search").QueryStruct(struct {
+		M map[string]int `query:"m"`
+	}{M: map[string]int{}})
+	xReq.baseURL = "https://api.example.com"
+	if _, err = xReq.processingURL(); err == nil || !strings.Contains(err.Error(), "query struct: field 'm'") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+type testQueryNode struct {
+	*testQueryNode
+	Name string `query:"name"`
+}
+
+func TestEncodeQueryStruct_embeddedSelf(t *testing.T) {
+	query, err := encodeQueryStruct(testQueryNode{testQueryNode: &testQueryNode{Name: "inner"}, Name: "outer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (urlpkg.Values{"name": {"outer"}}); !reflect.DeepEqual(query, want) {
+		t.Fatalf("query = %v, want %v", query, want)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
 	urlpkg "net/url"
 	"strconv"
 	"strings"
@@ -26,66 +27,78 @@ type XRequestBuilder struct {
 		has bool
 		v   any
 	}
+
+	debug    int8
+	onTiming func(t Timing)
+	tracer   *timingTracer
+	route    string
+	span     RequestSpan
+
+	validator     Validator
+	statusTargets statusTargets
+	respHeader    any
+
+	pathTemplate string
+	pathParams   map[string]string
+	queryStruct  any
+
+	// err is reported by build, e.g. an invalid Bind
+	err error
+
+	// pooled builders are from _xReqBuilderPool and returned to it once sent, see NewPooled
+	pooled bool
 }
 
 var _xReqBuilderPool = sync.Pool{
 	New: func() any {
-		return new(XRequestBuilder)
+		return &XRequestBuilder{pooled: true}
 	},
 }
 
-func NewGet() *XRequestBuilder {
+// NewPooled returns a request of the method from a pool, e.g. http.MethodGet, saving the allocations of the busy paths.
+// The request is recycled once it is sent, so it must not be used afterwards.
+// The requests of NewGet and friends are not pooled, they are left unchanged by the sends and can be sent again.
+func NewPooled(method string) *XRequestBuilder {
 	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodGet
+	xr.method = method
+	xr.pooled = true
 	return xr
 }
 
+func NewGet() *XRequestBuilder {
+	return &XRequestBuilder{method: http.MethodGet}
+}
+
 func NewHead() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodHead
-	return xr
+	return &XRequestBuilder{method: http.MethodHead}
 }
 
 func NewPost() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodPost
-	return xr
+	return &XRequestBuilder{method: http.MethodPost}
 }
 
 func NewPut() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodPut
-	return xr
+	return &XRequestBuilder{method: http.MethodPut}
 }
 
 func NewPatch() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodPatch
-	return xr
+	return &XRequestBuilder{method: http.MethodPatch}
 }
 
 func NewDelete() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodDelete
-	return xr
+	return &XRequestBuilder{method: http.MethodDelete}
 }
 
 func NewConnect() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodConnect
-	return xr
+	return &XRequestBuilder{method: http.MethodConnect}
 }
 
 func NewOptions() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodOptions
-	return xr
+	return &XRequestBuilder{method: http.MethodOptions}
 }
 
 func NewTrace() *XRequestBuilder {
-	xr := _xReqBuilderPool.Get().(*XRequestBuilder)
-	xr.method = http.MethodTrace
-	return xr
+	return &XRequestBuilder{method: http.MethodTrace}
 }
 
 func (xr *XRequestBuilder) WithContext(ctx context.Context) *XRequestBuilder {
@@ -124,7 +137,7 @@ func (xr *XRequestBuilder) SetBasicAuth(username, password string) *XRequestBuil
 }
 
 func (xr *XRequestBuilder) Query(query urlpkg.Values) *XRequestBuilder {
-	xr.query = query
+	xr.query = cloneValues(query)
 	return xr
 }
 
@@ -145,6 +158,7 @@ func (xr *XRequestBuilder) AddQuery(key, value string) *XRequestBuilder {
 }
 
 func (xr *XRequestBuilder) Path(elements ...string) *XRequestBuilder {
+	xr.pathTemplate = ""
 	xr.pathElements = make([]string, 0, len(elements))
 	for _, s := range elements {
 		if s = strings.TrimSpace(s); s != "" {
@@ -163,12 +177,16 @@ func (xr *XRequestBuilder) Body(body any) *XRequestBuilder {
 func (xr *XRequestBuilder) build(bc BodyCodec) (req *http.Request, cancel context.CancelFunc, err error) {
 	defer xr.free()
 
+	cancel = func() {}
+
+	if xr.err != nil {
+		return nil, cancel, xr.err
+	}
+
 	if xr.method == "" {
 		panic("'XRequestBuilder' is not reusable")
 	}
 
-	cancel = func() {}
-
 	u, err := xr.processingURL()
 	if err != nil {
 		return nil, cancel, fmt.Errorf("build url: %w", err)
@@ -176,6 +194,9 @@ func (xr *XRequestBuilder) build(bc BodyCodec) (req *http.Request, cancel contex
 
 	br, err := xr.processingBody(bc)
 	if err != nil {
+		if xr.span != nil {
+			xr.span.CodecError("encode", err)
+		}
 		return nil, cancel, fmt.Errorf("build body: %w", err)
 	}
 
@@ -196,6 +217,10 @@ func (xr *XRequestBuilder) build(bc BodyCodec) (req *http.Request, cancel contex
 		return nil, cancel, fmt.Errorf("build *http.Request: %w", err)
 	}
 
+	if xr.tracer != nil {
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), xr.tracer.clientTrace()))
+	}
+
 	for k, v := range xr.header {
 		req.Header[k] = append([]string{}, v...)
 	}
@@ -205,6 +230,14 @@ func (xr *XRequestBuilder) build(bc BodyCodec) (req *http.Request, cancel contex
 }
 
 func (xr *XRequestBuilder) processingURL() (u *urlpkg.URL, err error) {
+	if xr.pathTemplate != "" {
+		p, err := expandPathTemplate(xr.pathTemplate, xr.pathParams)
+		if err != nil {
+			return nil, err
+		}
+		xr.pathElements = []string{p}
+	}
+
 	switch {
 	case xr.baseURL == "" && len(xr.pathElements) == 0:
 		return nil, errors.New("empty url")
@@ -231,11 +264,20 @@ func (xr *XRequestBuilder) processingURL() (u *urlpkg.URL, err error) {
 		return nil, err
 	}
 
-	if len(xr.query) != 0 {
+	if len(xr.query) != 0 || xr.queryStruct != nil {
 		query, err := urlpkg.ParseQuery(u.RawQuery)
 		if err != nil {
 			return u, err
 		}
+		if xr.queryStruct != nil {
+			sq, err := encodeQueryStruct(xr.queryStruct)
+			if err != nil {
+				return u, fmt.Errorf("query struct: %w", err)
+			}
+			for k, vv := range sq {
+				query[k] = vv
+			}
+		}
 		for k, vv := range xr.query {
 			query[k] = vv
 		}
@@ -272,7 +314,54 @@ func (xr *XRequestBuilder) processingBody(bc BodyCodec) (r io.Reader, err error)
 	return
 }
 
+// Clone returns a deep copy of the request, e.g. to derive variants of a request.
+// The body value is shared, so an io.Reader body is not replayed.
+// The clone is not pooled, see NewPooled.
+func (xr *XRequestBuilder) Clone() *XRequestBuilder {
+	if xr.method == "" {
+		panic("'XRequestBuilder' is not reusable")
+	}
+	return xr.cloneTo(new(XRequestBuilder))
+}
+
+// sendable returns the builder to send: a pooled builder is sent and recycled itself,
+// the others are copied into a pooled builder, so that the sends leave them unchanged.
+func (xr *XRequestBuilder) sendable() *XRequestBuilder {
+	if xr.pooled {
+		return xr
+	}
+	if xr.method == "" && xr.err == nil {
+		panic("'XRequestBuilder' is not reusable")
+	}
+	return xr.cloneTo(_xReqBuilderPool.Get().(*XRequestBuilder))
+}
+
+// cloneTo copies xr into c, c remains pooled or not.
+func (xr *XRequestBuilder) cloneTo(c *XRequestBuilder) *XRequestBuilder {
+	pooled := c.pooled
+	*c = *xr
+	c.pooled = pooled
+
+	c.pathElements = append([]string(nil), xr.pathElements...)
+	c.header = xr.header.Clone()
+	c.query = cloneValues(xr.query)
+	c.statusTargets = xr.statusTargets.clone()
+	if xr.pathParams != nil {
+		c.pathParams = make(map[string]string, len(xr.pathParams))
+		for k, v := range xr.pathParams {
+			c.pathParams[k] = v
+		}
+	}
+	c.tracer = nil
+	c.span = nil
+	return c
+}
+
+// free recycles a pooled builder, the others are left unchanged.
 func (xr *XRequestBuilder) free() {
+	if !xr.pooled {
+		return
+	}
 	xr.reset()
 	_xReqBuilderPool.Put(xr)
 }
@@ -291,4 +380,29 @@ func (xr *XRequestBuilder) reset() {
 	}
 	xr.body.has = false
 	xr.body.v = nil
+	xr.debug = debugUnset
+	xr.onTiming = nil
+	xr.tracer = nil
+	xr.route = ""
+	xr.span = nil
+	xr.validator = nil
+	xr.statusTargets = statusTargets{}
+	xr.respHeader = nil
+	xr.pathTemplate = ""
+	for k := range xr.pathParams {
+		delete(xr.pathParams, k)
+	}
+	xr.queryStruct = nil
+	xr.err = nil
+}
+
+func cloneValues(v urlpkg.Values) urlpkg.Values {
+	if v == nil {
+		return nil
+	}
+	c := make(urlpkg.Values, len(v))
+	for k, vv := range v {
+		c[k] = append([]string(nil), vv...)
+	}
+	return c
 }
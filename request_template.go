@@ -0,0 +1,63 @@
+package xhttpclient
+
+import "io"
+
+// RequestTemplate is an immutable snapshot of a request, it is safe to materialize concurrently.
+//
+//	tmpl := NewRequestTemplate(NewGet().PathTemplate("/users/{id}").SetHeader("Accept", "application/json"))
+//	for _, id := range ids {
+//		var user User
+//		_, _, err := cli.Do(&user, nil, tmpl.New().PathParam("id", id))
+//	}
+//
+// The per-call outputs, i.e. OnStatus and OnStatusClass targets other than Discard, MatchedBranch and ResponseHeader,
+// would be shared by the requests, so they must be set on each request returned by New.
+// The Body value is shared too, it must not be modified once the template is created.
+type RequestTemplate struct {
+	xr     *XRequestBuilder
+	pooled bool
+}
+
+// NewRequestTemplate snapshots xr, the later changes of xr do not affect the template.
+// It panics if xr has per-call outputs or an io.Reader body, which can only be read once.
+func NewRequestTemplate(xr *XRequestBuilder) *RequestTemplate {
+	if xr.method == "" {
+		panic("'XRequestBuilder' is not reusable")
+	}
+	if xr.hasOutputs() {
+		panic("'RequestTemplate' must not hold OnStatus targets, MatchedBranch or ResponseHeader, set them on New()")
+	}
+	if _, ok := xr.body.v.(io.Reader); ok {
+		panic("'RequestTemplate' must not hold an io.Reader body")
+	}
+	return &RequestTemplate{xr: xr.cloneTo(new(XRequestBuilder))}
+}
+
+// Pooled returns a copy of the template materializing the requests from the pool of NewPooled,
+// saving the allocations of the busy paths. A pooled request must not be used once it is sent.
+func (t *RequestTemplate) Pooled() *RequestTemplate {
+	return &RequestTemplate{xr: t.xr, pooled: true}
+}
+
+// New returns a request initialized from the template, the request is a deep copy like XRequestBuilder.Clone.
+func (t *RequestTemplate) New() *XRequestBuilder {
+	if t.pooled {
+		return t.xr.cloneTo(_xReqBuilderPool.Get().(*XRequestBuilder))
+	}
+	return t.xr.cloneTo(new(XRequestBuilder))
+}
+
+// hasOutputs reports whether xr writes the response into the targets set by the caller.
+func (xr *XRequestBuilder) hasOutputs() bool {
+	if xr.respHeader != nil || xr.statusTargets.matched != nil {
+		return true
+	}
+	for _, targets := range []map[int]any{xr.statusTargets.codes, xr.statusTargets.classes} {
+		for _, v := range targets {
+			if v != Discard {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package xhttpclient
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRequestTemplate(t *testing.T) {
+	cli := NewClient().BaseURL("https://example.com").WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery+" "+r.Header.Get("X-Tenant"))
+	}))
+
+	xReq := NewGet().PathTemplate("/users/{id}").SetQuery("fields", "name").SetHeader("X-Tenant", "t")
+	tmpl := NewRequestTemplate(xReq)
+	xReq.SetHeader("X-Tenant", "changed").SetQuery("fields", "changed")
+
+	for _, tmpl := range []*RequestTemplate{tmpl, tmpl.Pooled()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				xr := tmpl.New().PathParam("id", id)
+				if id == "0" {
+					xr.SetQuery("fields", "id")
+				}
+				_, body, err := cli.Do(Discard, nil, xr)
+				want := "/users/" + id + "?fields=name t"
+				if id == "0" {
+					want = "/users/0?fields=id t"
+				}
+				if err != nil || string(body) != want {
+					t.Errorf("body = %q, want %q, err = %v", body, want, err)
+				}
+			}(strconv.Itoa(i))
+		}
+		wg.Wait()
+	}
+
+	if xr := tmpl.New(); xr.pooled {
+		t.Fatal("template materialized a pooled request")
+	}
+	if xr := tmpl.Pooled().New(); !xr.pooled {
+		t.Fatal("pooled template materialized an unpooled request")
+	}
+}
+
+func TestRequestTemplate_outputs(t *testing.T) {
+	cli := NewClient().BaseURL("https://example.com").WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		code, _ := strconv.Atoi(r.URL.Query().Get("code"))
+		w.Header().Set("X-Code", r.URL.Query().Get("code"))
+		w.WriteHeader(code)
+		io.WriteString(w, `{"code":`+r.URL.Query().Get("code")+`}`)
+	}))
+	tmpl := NewRequestTemplate(NewGet().Path("/status").OnStatus(http.StatusNotFound, Discard))
+
+	for _, tmpl := range []*RequestTemplate{tmpl, tmpl.Pooled()} {
+		var wg sync.WaitGroup
+		for i := 0; i < 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				code := http.StatusOK + i%2*(http.StatusConflict-http.StatusOK)
+				var (
+					success, conflict struct{ Code int }
+					branch            DecodeBranch
+					header            struct {
+						Code int `header:"X-Code"`
+					}
+				)
+				xr := tmpl.New().SetQuery("code", strconv.Itoa(code)).
+					OnStatus(http.StatusConflict, &conflict).MatchedBranch(&branch).ResponseHeader(&header)
+				if _, _, err := cli.Do(&success, nil, xr); err != nil {
+					t.Error(err)
+					return
+				}
+				want, wantBranch := success.Code, DecodeBranchSuccess
+				if code == http.StatusConflict {
+					want, wantBranch = conflict.Code, DecodeBranchStatus
+				}
+				if want != code || branch != wantBranch || header.Code != code {
+					t.Errorf("code %d: decoded %d, branch %v, header %d", code, want, branch, header.Code)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}
+}
+
+func TestNewRequestTemplate_panic(t *testing.T) {
+	var (
+		v      struct{}
+		branch DecodeBranch
+	)
+	for name, xr := range map[string]*XRequestBuilder{
+		"OnStatus":       NewGet().OnStatus(http.StatusConflict, &v),
+		"OnStatusClass":  NewGet().OnStatusClass(4, &v),
+		"MatchedBranch":  NewGet().MatchedBranch(&branch),
+		"ResponseHeader": NewGet().ResponseHeader(&v),
+		"io.Reader":      NewPost().Body(strings.NewReader("body")),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: NewRequestTemplate does not panic", name)
+				}
+			}()
+			NewRequestTemplate(xr)
+		}()
+	}
+}
@@ -715,3 +715,190 @@ func TestXRequestBuilder_Build_GetBody(t *testing.T) {
 		})
 	}
 }
+
+func TestXRequestBuilder_Build_PathTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		xReq    *XRequestBuilder
+		baseURL string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "params",
+			xReq:    NewGet().PathTemplate("/users/{id}/repos/{repo}").PathParam("id", "a/b").PathParam("repo", "x y?"),
+			baseURL: "http://127.0.0.1/api",
+			want:    "http://127.0.0.1/api/users/a%2Fb/repos/x%20y%3F",
+		},
+		{
+			name:    "dot_segment",
+			xReq:    NewGet().PathTemplate("/users/{id}/repos").PathParam("id", ".."),
+			baseURL: "http://127.0.0.1",
+			want:    "http://127.0.0.1/users/%2E%2E/repos",
+		},
+		{
+			name:    "absolute",
+			xReq:    NewGet().PathTemplate("http://127.0.0.1/users/{id}?fields=name").PathParam("id", "1"),
+			baseURL: "",
+			want:    "http://127.0.0.1/users/1?fields=name",
+		},
+		{
+			name:    "no_params",
+			xReq:    NewGet().PathTemplate("users"),
+			baseURL: "http://127.0.0.1",
+			want:    "http://127.0.0.1/users",
+		},
+		{
+			name:    "path_overrides",
+			xReq:    NewGet().PathTemplate("/users/{id}").Path("items"),
+			baseURL: "http://127.0.0.1",
+			want:    "http://127.0.0.1/items",
+		},
+		{
+			name:    "missing",
+			xReq:    NewGet().PathTemplate("/users/{id}/repos/{repo}").PathParam("id", "1"),
+			baseURL: "http://127.0.0.1",
+			wantErr: "missing param 'repo'",
+		},
+		{
+			name:    "unknown",
+			xReq:    NewGet().PathTemplate("/users/{id}").PathParam("id", "1").PathParam("repo", "x").PathParam("org", "o"),
+			baseURL: "http://127.0.0.1",
+			wantErr: "unknown params 'org', 'repo'",
+		},
+		{
+			name:    "empty",
+			xReq:    NewGet().PathTemplate("/users/{id}").PathParam("id", ""),
+			baseURL: "http://127.0.0.1",
+			wantErr: "empty param 'id'",
+		},
+		{
+			name:    "unclosed",
+			xReq:    NewGet().PathTemplate("/users/{id"),
+			baseURL: "http://127.0.0.1",
+			wantErr: "unclosed '{'",
+		},
+		{
+			name:    "unexpected",
+			xReq:    NewGet().PathTemplate("/users/id}"),
+			baseURL: "http://127.0.0.1",
+			wantErr: "unexpected '}'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.xReq.baseURL = tt.baseURL
+			u, err := tt.xReq.processingURL()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("xReq.processingURL() = %v; want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("xReq.processingURL() = %v; want nil", err)
+				return
+			}
+
+			if u.String() != tt.want {
+				t.Errorf("u.String() = %v, want %v", u.String(), tt.want)
+				return
+			}
+		})
+	}
+}
+
+func TestXRequestBuilder_routeLabel(t *testing.T) {
+	if got := NewGet().PathTemplate("/users/{id}").PathParam("id", "1").routeLabel(); got != "/users/{id}" {
+		t.Fatalf("routeLabel() = %q, want the template", got)
+	}
+	if got := NewGet().PathTemplate("/users/{id}").Route("users").routeLabel(); got != "users" {
+		t.Fatalf("routeLabel() = %q, want the route", got)
+	}
+}
+
+func TestXRequestBuilder_Clone(t *testing.T) {
+	var requests []string
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.String()+" "+r.Header.Get("X-Tenant"))
+		w.WriteHeader(http.StatusConflict)
+		io.WriteString(w, "{}")
+	}))
+
+	var conflict struct{}
+	branch := DecodeBranchNone
+	xReq := NewPut().
+		PathTemplate("https://example.com/users/{id}").
+		PathParam("id", "1").
+		Query(urlpkg.Values{"a": {"1"}}).
+		SetHeader("X-Tenant", "t").
+		OnStatus(http.StatusConflict, Discard).
+		MatchedBranch(&branch)
+	clone := xReq.Clone()
+	clone.PathParam("id", "2").SetQuery("b", "2").OnStatus(http.StatusConflict, &conflict)
+	retry := clone.Clone()
+
+	for _, xr := range []*XRequestBuilder{xReq, clone, retry} {
+		if _, _, err := cli.Do(Discard, nil, xr); err != nil || branch != DecodeBranchStatus {
+			t.Fatalf("branch = %s, err = %v", branch, err)
+		}
+	}
+	want := []string{
+		"PUT https://example.com/users/1?a=1 t",
+		"PUT https://example.com/users/2?a=1&b=2 t",
+		"PUT https://example.com/users/2?a=1&b=2 t",
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("requests = %q, want %q", requests, want)
+	}
+
+	// the sends leave the requests unchanged
+	requests = nil
+	if _, _, err := cli.Do(Discard, nil, xReq); err != nil || !reflect.DeepEqual(requests, want[:1]) {
+		t.Fatalf("requests = %q, err = %v", requests, err)
+	}
+
+	pooled := NewPooled(http.MethodPut).Path("https://example.com").OnStatus(http.StatusConflict, Discard)
+	if _, _, err := cli.Do(Discard, nil, pooled); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("clone of a sent pooled request does not panic")
+		}
+	}()
+	pooled.Clone()
+}
+
+func TestXRequestBuilder_reuse(t *testing.T) {
+	var requests []string
+	cli := NewClient().SetHeader("X-Client", "c").WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.String()+" "+r.Header.Get("X-Client"))
+		io.WriteString(w, "{}")
+	}))
+	xReq := NewGet().PathTemplate("/users/{id}").PathParam("id", "1")
+
+	cli.BaseURL("https://a.example.com")
+	if _, _, err := cli.Do(Discard, nil, xReq); err != nil {
+		t.Fatal(err)
+	}
+	cli.BaseURL("https://b.example.com").SetHeader("X-Client", "d")
+	if _, _, err := cli.Do(Discard, nil, xReq); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"https://a.example.com/users/1 c", "https://b.example.com/users/1 d"}; !reflect.DeepEqual(requests, want) {
+		t.Fatalf("requests = %q, want %q", requests, want)
+	}
+	if xReq.baseURL != "" || len(xReq.header) != 0 || xReq.ctx != nil {
+		t.Fatalf("the request is changed by the sends: %+v", xReq)
+	}
+}
+
+func TestXRequestBuilder_Query_copy(t *testing.T) {
+	query := urlpkg.Values{"a": {"1"}}
+	NewPooled(http.MethodGet).Query(query).free()
+	if query.Get("a") != "1" {
+		t.Fatalf("query = %v, the caller's values are cleared", query)
+	}
+}
@@ -0,0 +1,97 @@
+package xhttpclient
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// ResponseHeader decodes the headers of the response into the struct v by DoOnceWithBodyCodec, see DecodeHeader.
+//
+//	type Page struct {
+//		Total     int           `header:"X-Total-Count"`
+//		Reset     time.Time     `header:"X-RateLimit-Reset,unix"`
+//		Retry     time.Duration `header:"Retry-After"`
+//		RequestID string        `header:"X-Request-Id"`
+//	}
+func (xr *XRequestBuilder) ResponseHeader(v any) *XRequestBuilder {
+	xr.respHeader = v
+	return xr
+}
+
+// DecodeHeader decodes h into the exported fields of the struct v tagged with `header:"Name,opts"`,
+// a slice gets an element per comma-separated list member of the values, the commas in quoted strings and
+// in angle brackets excluded, and the missing headers leave the fields unchanged.
+//
+// The time.Time fields are parsed as RFC 3339 or HTTP dates, or by the 'unix' and 'unixmilli' options
+// or the `layout:"..."` tag. The time.Duration fields accept "1m30s", the seconds like "90"
+// and the HTTP dates like Retry-After, as the duration until the date.
+// The untagged fields are ignored, the untagged embedded structs are flattened.
+func DecodeHeader(h http.Header, v any) error {
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+	if !rv.CanSet() {
+		return fmt.Errorf("non-pointer %T", v)
+	}
+	for _, f := range cachedStructFields(rv.Type(), "header") {
+		values := h.Values(f.name)
+		if len(values) == 0 {
+			continue
+		}
+		fv, ok := settableFieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		if isListType(fv.Type()) {
+			values = splitHeaderList(values)
+		}
+		if err = parseValues(values, fv, f); err != nil {
+			return fmt.Errorf("header '%s': %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// isListType reports whether t is parsed as a slice by parseValues.
+func isListType(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 &&
+		!reflect.PointerTo(t).Implements(_textUnmarshalerType)
+}
+
+// splitHeaderList splits the values into the members of the comma-separated lists, the empty members are dropped.
+func splitHeaderList(values []string) []string {
+	var members []string
+	add := func(m string) {
+		if m = strings.TrimSpace(m); m != "" {
+			members = append(members, m)
+		}
+	}
+	for _, v := range values {
+		quoted, bracketed, start := false, false, 0
+		for i := 0; i < len(v); i++ {
+			switch c := v[i]; {
+			case c == '\\' && quoted:
+				i++
+			case c == '"' && !bracketed:
+				quoted = !quoted
+			case c == '<' && !quoted:
+				bracketed = true
+			case c == '>' && !quoted:
+				bracketed = false
+			case c == ',' && !quoted && !bracketed:
+				add(v[start:i])
+				start = i + 1
+			}
+		}
+		if start < len(v) {
+			add(v[start:])
+		}
+	}
+	return members
+}
@@ -0,0 +1,112 @@
+package xhttpclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXRequestBuilder_ResponseHeader(t *testing.T) {
+	type RateLimit struct {
+		Remaining *int      `header:"X-RateLimit-Remaining"`
+		Reset     time.Time `header:"X-RateLimit-Reset,unix"`
+	}
+	type Page struct {
+		*RateLimit
+		Total     int64         `header:"X-Total-Count"`
+		Date      time.Time     `header:"Date"`
+		Expires   time.Time     `header:"X-Expires" layout:"2006-01-02"`
+		Retry     time.Duration `header:"Retry-After"`
+		Timeout   time.Duration `header:"X-Timeout"`
+		Ratio     float64       `header:"X-Ratio"`
+		Cached    bool          `header:"X-Cached"`
+		Links     []string      `header:"Link"`
+		Tags      []string      `header:"X-Tags"`
+		IDs       []int         `header:"X-Ids"`
+		RetryAt   time.Duration `header:"X-Retry-At"`
+		IP        net.IP        `header:"X-Client-IP"`
+		RequestID string        `header:"X-Request-Id"`
+		Missing   string        `header:"X-Missing"`
+		Ignored   string
+	}
+
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-RateLimit-Remaining", "59")
+		h.Set("X-RateLimit-Reset", "1700000000")
+		h.Set("X-Total-Count", "120")
+		h.Set("Date", "Tue, 14 Nov 2023 22:13:20 GMT")
+		h.Set("X-Expires", "2024-01-02")
+		h.Set("Retry-After", "90")
+		h.Set("X-Timeout", "1m30s")
+		h.Set("X-Ratio", "0.5")
+		h.Set("X-Cached", "true")
+		h.Add("Link", `</a>; rel="next", </b,c>; title="x, y"; rel="last"`)
+		h.Add("Link", `</c>; rel="prev"`)
+		h.Add("X-Tags", "a, b,,")
+		h.Add("X-Tags", "c")
+		h.Set("X-Ids", "1, 2")
+		h.Set("X-Retry-At", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		h.Set("X-Client-IP", "192.0.2.1")
+		h.Set("X-Request-Id", "req-1")
+		h.Set("Ignored", "x")
+		if r.URL.Query().Has("invalid") {
+			h.Set("X-Total-Count", "many")
+		}
+		io.WriteString(w, `{"name":"body"}`)
+	}))
+
+	page := Page{Missing: "unchanged"}
+	if _, _, err := cli.Do(Discard, nil, NewGet().Path("http://example.com").ResponseHeader(&page)); err != nil {
+		t.Fatal(err)
+	}
+	if page.RetryAt <= 59*time.Minute || page.RetryAt > time.Hour {
+		t.Fatalf("RetryAt = %s", page.RetryAt)
+	}
+	page.RetryAt = 0
+	remaining := 59
+	want := Page{
+		RateLimit: &RateLimit{Remaining: &remaining, Reset: time.Unix(1700000000, 0)},
+		Total:     120,
+		Date:      time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC),
+		Expires:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Retry:     90 * time.Second,
+		Timeout:   90 * time.Second,
+		Ratio:     0.5,
+		Cached:    true,
+		Links:     []string{`</a>; rel="next"`, `</b,c>; title="x, y"; rel="last"`, `</c>; rel="prev"`},
+		Tags:      []string{"a", "b", "c"},
+		IDs:       []int{1, 2},
+		IP:        net.ParseIP("192.0.2.1"),
+		RequestID: "req-1",
+		Missing:   "unchanged",
+	}
+	if !reflect.DeepEqual(page, want) {
+		t.Fatalf("page = %+v\nwant %+v", page, want)
+	}
+
+	var body struct{ Name string }
+	_, _, err := cli.Do(&body, nil, NewGet().Path("http://example.com").SetQuery("invalid", "").ResponseHeader(&page))
+	if err == nil || !strings.Contains(err.Error(), "decode header: header 'X-Total-Count'") {
+		t.Fatalf("err = %v", err)
+	}
+	if body.Name != "body" {
+		t.Fatalf("body is not decoded before the header error: %+v", body)
+	}
+}
+
+func TestDecodeHeader_errors(t *testing.T) {
+	var v struct {
+		N int `header:"N"`
+	}
+	if err := DecodeHeader(http.Header{}, v); err == nil {
+		t.Fatal("non-pointer is decoded")
+	}
+	if err := DecodeHeader(http.Header{}, new(int)); err == nil {
+		t.Fatal("non-struct is decoded")
+	}
+}
@@ -0,0 +1,53 @@
+package xhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+type RequestSigner interface {
+	SignRequest(req *http.Request, body []byte) error
+}
+
+type ResponseVerifier interface {
+	VerifyResponse(resp *http.Response, body []byte) error
+}
+
+func (xc *XClient) WithRequestSigner(signer RequestSigner) *XClient {
+	xc.signer = signer
+	return xc
+}
+
+// WithResponseVerifier verifies responses before decoding,
+// it only applies to Do and DoOnceWithBodyCodec since DoWithRaw leaves the body to the caller.
+func (xc *XClient) WithResponseVerifier(verifier ResponseVerifier) *XClient {
+	xc.verifier = verifier
+	return xc
+}
+
+func signRequest(signer RequestSigner, req *http.Request) error {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return err
+	}
+	return signer.SignRequest(req, body)
+}
+
+// readRequestBody returns the encoded body without consuming req.Body.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body is not replayable")
+	}
+
+	rc, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
@@ -0,0 +1,276 @@
+package xhttpclient
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	urlpkg "net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var _ RequestSigner = (*HMACSigner)(nil)
+
+type HMACPlacement int
+
+const (
+	// HMACPlacementHeader puts the timestamp, nonce and signature into request headers.
+	HMACPlacementHeader HMACPlacement = iota
+	// HMACPlacementQuery puts the timestamp and nonce into the query before canonicalization,
+	// so that they are covered by the signature, and appends the signature as the last query parameter.
+	HMACPlacementQuery
+)
+
+// HMACCanonicalRequest is the input of HMACCanonicalizer.
+type HMACCanonicalRequest struct {
+	Method string
+	Host   string
+	// Path is the escaped path of the request URL.
+	Path string
+	// Query is the query of the request URL, sorted by key.
+	Query  string
+	Params urlpkg.Values
+	Header http.Header
+	Body   []byte
+
+	Timestamp string
+	Nonce     string
+}
+
+type HMACCanonicalizer func(cr *HMACCanonicalRequest) ([]byte, error)
+
+// HMACCanonicalNewline joins method, path, sorted query, timestamp, nonce and body with '\n'.
+func HMACCanonicalNewline(cr *HMACCanonicalRequest) ([]byte, error) {
+	buf := getBytesBuffer()
+	defer putBytesBuffer(buf)
+
+	for _, s := range []string{cr.Method, cr.Path, cr.Query, cr.Timestamp, cr.Nonce} {
+		buf.WriteString(s)
+		buf.WriteByte('\n')
+	}
+	buf.Write(cr.Body)
+
+	return append([]byte{}, buf.Bytes()...), nil
+}
+
+// NewHMACCanonicalTemplate parses text as a text/template executed with *HMACCanonicalRequest.
+//
+// Besides the builtin functions, the template can use:
+//
+//	string, lower, upper, sha256hex, md5hex, base64
+//
+// e.g.
+//
+//	{{.Method}}\n{{.Path}}\n{{.Query}}\n{{.Timestamp}}\n{{.Nonce}}\n{{sha256hex .Body}}
+func NewHMACCanonicalTemplate(text string) (HMACCanonicalizer, error) {
+	tmpl, err := template.New("hmac").Funcs(_hmacTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(cr *HMACCanonicalRequest) ([]byte, error) {
+		buf := getBytesBuffer()
+		defer putBytesBuffer(buf)
+		if err := tmpl.Execute(buf, cr); err != nil {
+			return nil, err
+		}
+		return append([]byte{}, buf.Bytes()...), nil
+	}, nil
+}
+
+var _hmacTemplateFuncs = template.FuncMap{
+	"string": func(b []byte) string { return string(b) },
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"sha256hex": func(b []byte) string {
+		sum := sha256.Sum256(b)
+		return hex.EncodeToString(sum[:])
+	},
+	"md5hex": func(b []byte) string {
+		sum := md5.Sum(b)
+		return hex.EncodeToString(sum[:])
+	},
+	"base64": func(b []byte) string { return base64.StdEncoding.EncodeToString(b) },
+}
+
+type HMACSigner struct {
+	key          []byte
+	hash         func() hash.Hash
+	canonicalize HMACCanonicalizer
+	encode       func([]byte) string
+	placement    HMACPlacement
+	now          func() time.Time
+	nonce        func() string
+
+	signatureKey    string
+	signaturePrefix string
+	timestampKey    string
+	timestampFormat func(time.Time) string
+	nonceKey        string
+	keyIDKey        string
+	keyID           string
+}
+
+// NewHMACSigner returns a HMAC-SHA256 signer which canonicalizes requests with HMACCanonicalNewline
+// and writes the hex encoded signature to the 'X-Signature' header,
+// along with 'X-Timestamp' (unix seconds) and 'X-Nonce'.
+func NewHMACSigner(key []byte) *HMACSigner {
+	return &HMACSigner{
+		key:          append([]byte{}, key...),
+		hash:         sha256.New,
+		canonicalize: HMACCanonicalNewline,
+		encode:       hex.EncodeToString,
+		placement:    HMACPlacementHeader,
+		now:          time.Now,
+		nonce:        randomNonce,
+
+		signatureKey:    "X-Signature",
+		timestampKey:    "X-Timestamp",
+		timestampFormat: func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+		nonceKey:        "X-Nonce",
+	}
+}
+
+func (s *HMACSigner) WithHash(h func() hash.Hash) *HMACSigner {
+	s.hash = h
+	return s
+}
+
+func (s *HMACSigner) WithCanonicalizer(c HMACCanonicalizer) *HMACSigner {
+	s.canonicalize = c
+	return s
+}
+
+// WithEncoding sets how the raw signature is encoded, e.g. base64.StdEncoding.EncodeToString.
+func (s *HMACSigner) WithEncoding(encode func([]byte) string) *HMACSigner {
+	s.encode = encode
+	return s
+}
+
+func (s *HMACSigner) WithPlacement(p HMACPlacement) *HMACSigner {
+	s.placement = p
+	return s
+}
+
+// WithSignature sets the header (or query) key of the signature, prefix is prepended to the encoded signature.
+func (s *HMACSigner) WithSignature(key, prefix string) *HMACSigner {
+	s.signatureKey = key
+	s.signaturePrefix = prefix
+	return s
+}
+
+// WithTimestamp sets the header (or query) key of the timestamp, an empty key omits it.
+// A nil format keeps the current one.
+func (s *HMACSigner) WithTimestamp(key string, format func(time.Time) string) *HMACSigner {
+	s.timestampKey = key
+	if format != nil {
+		s.timestampFormat = format
+	}
+	return s
+}
+
+// WithNonce sets the header (or query) key of the nonce, an empty key omits it.
+// A nil generate keeps the current one.
+func (s *HMACSigner) WithNonce(key string, generate func() string) *HMACSigner {
+	s.nonceKey = key
+	if generate != nil {
+		s.nonce = generate
+	}
+	return s
+}
+
+// WithKeyID sends id with the header (or query) key, it is placed before canonicalization.
+func (s *HMACSigner) WithKeyID(key, id string) *HMACSigner {
+	s.keyIDKey = key
+	s.keyID = id
+	return s
+}
+
+func (s *HMACSigner) WithClock(now func() time.Time) *HMACSigner {
+	s.now = now
+	return s
+}
+
+func (s *HMACSigner) SignRequest(req *http.Request, body []byte) error {
+	if s.signatureKey == "" {
+		return fmt.Errorf("hmac: empty signature key")
+	}
+
+	var timestamp, nonce string
+	if s.timestampKey != "" {
+		timestamp = s.timestampFormat(s.now())
+	}
+	if s.nonceKey != "" {
+		nonce = s.nonce()
+	}
+
+	params := req.URL.Query()
+	place := func(key, value string) {
+		if key == "" {
+			return
+		}
+		if s.placement == HMACPlacementQuery {
+			params.Set(key, value)
+		} else {
+			req.Header.Set(key, value)
+		}
+	}
+	place(s.keyIDKey, s.keyID)
+	place(s.timestampKey, timestamp)
+	place(s.nonceKey, nonce)
+	if s.placement == HMACPlacementQuery {
+		req.URL.RawQuery = params.Encode()
+	}
+
+	msg, err := s.canonicalize(&HMACCanonicalRequest{
+		Method:    req.Method,
+		Host:      req.URL.Host,
+		Path:      req.URL.EscapedPath(),
+		Query:     params.Encode(),
+		Params:    params,
+		Header:    req.Header,
+		Body:      body,
+		Timestamp: timestamp,
+		Nonce:     nonce,
+	})
+	if err != nil {
+		return fmt.Errorf("hmac: canonicalize: %w", err)
+	}
+
+	mac := hmac.New(s.hash, s.key)
+	mac.Write(msg)
+	signature := s.signaturePrefix + s.encode(mac.Sum(nil))
+
+	if s.placement == HMACPlacementQuery {
+		buf := bytes.NewBufferString(req.URL.RawQuery)
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(urlpkg.QueryEscape(s.signatureKey))
+		buf.WriteByte('=')
+		buf.WriteString(urlpkg.QueryEscape(signature))
+		req.URL.RawQuery = buf.String()
+	} else {
+		req.Header.Set(s.signatureKey, signature)
+	}
+
+	return nil
+}
+
+func randomNonce() string {
+	var buf [16]byte
+	if _, err := io.ReadFull(rand.Reader, buf[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf[:])
+}
@@ -0,0 +1,116 @@
+package xhttpclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHMACSigner_SignRequest(t *testing.T) {
+	key := []byte("secret")
+	now := time.Unix(1700000000, 0)
+
+	tmpl, err := NewHMACCanonicalTemplate(`{{.Method}}&{{.Path}}&{{.Query}}&{{sha256hex .Body}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		signer *HMACSigner
+		want   func(r *http.Request, body []byte) (got, want string)
+	}{
+		{
+			name:   "header",
+			signer: NewHMACSigner(key).WithClock(func() time.Time { return now }).WithNonce("X-Nonce", func() string { return "n1" }),
+			want: func(r *http.Request, body []byte) (string, string) {
+				msg := "POST\n/p1/p2\na=1&b=2&b=3\n1700000000\nn1\n" + string(body)
+				return r.Header.Get("X-Signature"), testHMACSHA256Hex(key, msg)
+			},
+		},
+		{
+			name: "query",
+			signer: NewHMACSigner(key).
+				WithPlacement(HMACPlacementQuery).
+				WithSignature("sign", "").
+				WithTimestamp("timestamp", nil).
+				WithNonce("", nil).
+				WithKeyID("app_id", "x").
+				WithClock(func() time.Time { return now }),
+			want: func(r *http.Request, body []byte) (string, string) {
+				msg := "POST\n/p1/p2\na=1&app_id=x&b=2&b=3&timestamp=1700000000\n1700000000\n\n" + string(body)
+				return r.URL.Query().Get("sign"), testHMACSHA256Hex(key, msg)
+			},
+		},
+		{
+			name: "template",
+			signer: NewHMACSigner(key).
+				WithCanonicalizer(tmpl).
+				WithEncoding(base64.StdEncoding.EncodeToString).
+				WithSignature("Authorization", "HMAC-SHA256 ").
+				WithTimestamp("", nil).
+				WithNonce("", nil),
+			want: func(r *http.Request, body []byte) (string, string) {
+				sum := sha256.Sum256(body)
+				mac := hmac.New(sha256.New, key)
+				mac.Write([]byte("POST&/p1/p2&a=1&b=2&b=3&" + hex.EncodeToString(sum[:])))
+				return r.Header.Get("Authorization"), "HMAC-SHA256 " + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					if got, want := tt.want(r, body); got != want {
+						t.Errorf("signature = %s, want %s", got, want)
+					}
+					w.WriteHeader(http.StatusNoContent)
+				}),
+			)
+			defer ts.Close()
+
+			cli := NewClient().BaseURL(ts.URL).WithRequestSigner(tt.signer)
+			var successV any
+			_, _, err := cli.Do(&successV, nil,
+				NewPost().
+					Path("/p1", "p2?b=2&b=3").
+					SetQuery("a", "1").
+					Body(map[string]string{"hello": "world"}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func testHMACSHA256Hex(key []byte, msg string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACSigner_SignRequest_queryInPath(t *testing.T) {
+	key := []byte("secret")
+	signer := NewHMACSigner(key).WithTimestamp("", nil).WithNonce("", nil)
+
+	cli := NewClient().WithRequestSigner(signer).WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := "GET\n/x\na=2&b=1&b=0\n\n\n"
+		if got, want := r.Header.Get("X-Signature"), testHMACSHA256Hex(key, msg); got != want {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	if _, _, err := cli.Do(Discard, nil, NewGet().Path("https://example.com/x?b=1&a=2&b=0")); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,575 @@
+package xhttpclient
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTP Message Signatures, see https://www.rfc-editor.org/rfc/rfc9421
+
+const (
+	SignatureAlgorithmEd25519         = "ed25519"
+	SignatureAlgorithmECDSAP256SHA256 = "ecdsa-p256-sha256"
+	SignatureAlgorithmECDSAP384SHA384 = "ecdsa-p384-sha384"
+	SignatureAlgorithmHMACSHA256      = "hmac-sha256"
+)
+
+var (
+	ErrSignatureMissing    = errors.New("missing signature")
+	ErrSignatureInvalid    = errors.New("invalid signature")
+	ErrSignatureExpired    = errors.New("signature expired")
+	ErrSignatureKeyUnknown = errors.New("unknown signature key")
+	ErrSignatureCoverage   = errors.New("required component not covered")
+)
+
+// MessageSignatureError is returned when a response fails the verification of MessageVerifier,
+// Err is one of ErrSignature* or a more detailed error.
+type MessageSignatureError struct {
+	Label string
+	Err   error
+}
+
+func (e *MessageSignatureError) Error() string {
+	if e.Label == "" {
+		return "http message signature: " + e.Err.Error()
+	}
+	return fmt.Sprintf("http message signature %q: %s", e.Label, e.Err)
+}
+
+func (e *MessageSignatureError) Unwrap() error {
+	return e.Err
+}
+
+type SignatureKey interface {
+	KeyID() string
+	Algorithm() string
+	// Sign returns an error if the key can only verify.
+	Sign(base []byte) ([]byte, error)
+	Verify(base, signature []byte) error
+}
+
+var (
+	_ SignatureKey = (*signatureKeyEd25519)(nil)
+	_ SignatureKey = (*signatureKeyECDSA)(nil)
+	_ SignatureKey = (*signatureKeyHMAC)(nil)
+)
+
+type signatureKeyEd25519 struct {
+	id   string
+	priv ed25519.PrivateKey
+	pub  ed25519.PublicKey
+}
+
+func NewSignatureKeyEd25519(keyID string, priv ed25519.PrivateKey) SignatureKey {
+	return &signatureKeyEd25519{id: keyID, priv: priv, pub: priv.Public().(ed25519.PublicKey)}
+}
+
+func NewSignatureKeyEd25519Public(keyID string, pub ed25519.PublicKey) SignatureKey {
+	return &signatureKeyEd25519{id: keyID, pub: pub}
+}
+
+func (k *signatureKeyEd25519) KeyID() string     { return k.id }
+func (k *signatureKeyEd25519) Algorithm() string { return SignatureAlgorithmEd25519 }
+
+func (k *signatureKeyEd25519) Sign(base []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errors.New("ed25519: missing private key")
+	}
+	return ed25519.Sign(k.priv, base), nil
+}
+
+func (k *signatureKeyEd25519) Verify(base, signature []byte) error {
+	if !ed25519.Verify(k.pub, base, signature) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+type signatureKeyECDSA struct {
+	id   string
+	alg  string
+	hash crypto.Hash
+	priv *ecdsa.PrivateKey
+	pub  *ecdsa.PublicKey
+}
+
+// NewSignatureKeyECDSA supports the P-256 and P-384 curves.
+func NewSignatureKeyECDSA(keyID string, priv *ecdsa.PrivateKey) (SignatureKey, error) {
+	k, err := NewSignatureKeyECDSAPublic(keyID, &priv.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	k.(*signatureKeyECDSA).priv = priv
+	return k, nil
+}
+
+// NewSignatureKeyECDSAPublic supports the P-256 and P-384 curves.
+func NewSignatureKeyECDSAPublic(keyID string, pub *ecdsa.PublicKey) (SignatureKey, error) {
+	k := &signatureKeyECDSA{id: keyID, pub: pub}
+	switch pub.Curve {
+	case elliptic.P256():
+		k.alg, k.hash = SignatureAlgorithmECDSAP256SHA256, crypto.SHA256
+	case elliptic.P384():
+		k.alg, k.hash = SignatureAlgorithmECDSAP384SHA384, crypto.SHA384
+	default:
+		return nil, fmt.Errorf("ecdsa: unsupported curve '%s'", pub.Curve.Params().Name)
+	}
+	return k, nil
+}
+
+func (k *signatureKeyECDSA) KeyID() string     { return k.id }
+func (k *signatureKeyECDSA) Algorithm() string { return k.alg }
+
+func (k *signatureKeyECDSA) digest(base []byte) []byte {
+	if k.hash == crypto.SHA384 {
+		sum := sha512.Sum384(base)
+		return sum[:]
+	}
+	sum := sha256.Sum256(base)
+	return sum[:]
+}
+
+// Sign returns r and s as fixed-size big-endian integers concatenated, see RFC 9421 section 3.3.4.
+func (k *signatureKeyECDSA) Sign(base []byte) ([]byte, error) {
+	if k.priv == nil {
+		return nil, errors.New("ecdsa: missing private key")
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, k.priv, k.digest(base))
+	if err != nil {
+		return nil, err
+	}
+	size := (k.pub.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+
+func (k *signatureKeyECDSA) Verify(base, signature []byte) error {
+	size := (k.pub.Curve.Params().BitSize + 7) / 8
+	if len(signature) != 2*size {
+		return ErrSignatureInvalid
+	}
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if !ecdsa.Verify(k.pub, k.digest(base), r, s) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+type signatureKeyHMAC struct {
+	id     string
+	secret []byte
+}
+
+func NewSignatureKeyHMACSHA256(keyID string, secret []byte) SignatureKey {
+	return &signatureKeyHMAC{id: keyID, secret: append([]byte{}, secret...)}
+}
+
+func (k *signatureKeyHMAC) KeyID() string     { return k.id }
+func (k *signatureKeyHMAC) Algorithm() string { return SignatureAlgorithmHMACSHA256 }
+
+func (k *signatureKeyHMAC) Sign(base []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write(base)
+	return mac.Sum(nil), nil
+}
+
+func (k *signatureKeyHMAC) Verify(base, signature []byte) error {
+	expected, _ := k.Sign(base)
+	if !hmac.Equal(expected, signature) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+var _ RequestSigner = (*MessageSigner)(nil)
+
+type MessageSigner struct {
+	key        SignatureKey
+	label      string
+	components []string
+	expires    time.Duration
+	nonce      func() string
+	tag        string
+	now        func() time.Time
+}
+
+// NewMessageSigner covers the given components, e.g. "@method", "@target-uri", "content-digest".
+// Without components it covers "@method", "@target-uri"
+// and "content-digest", "content-type" when present in the request.
+func NewMessageSigner(key SignatureKey, components ...string) *MessageSigner {
+	return &MessageSigner{
+		key:        key,
+		label:      "sig1",
+		components: append([]string{}, components...),
+		now:        time.Now,
+	}
+}
+
+func (s *MessageSigner) WithLabel(label string) *MessageSigner {
+	s.label = label
+	return s
+}
+
+// WithExpires adds the 'expires' parameter, d after 'created'.
+func (s *MessageSigner) WithExpires(d time.Duration) *MessageSigner {
+	s.expires = d
+	return s
+}
+
+// WithNonce adds the 'nonce' parameter, a nil generate uses a random one.
+func (s *MessageSigner) WithNonce(generate func() string) *MessageSigner {
+	if generate == nil {
+		generate = randomNonce
+	}
+	s.nonce = generate
+	return s
+}
+
+func (s *MessageSigner) WithTag(tag string) *MessageSigner {
+	s.tag = tag
+	return s
+}
+
+func (s *MessageSigner) WithClock(now func() time.Time) *MessageSigner {
+	s.now = now
+	return s
+}
+
+func (s *MessageSigner) SignRequest(req *http.Request, _ []byte) error {
+	components := s.components
+	if len(components) == 0 {
+		components = []string{"@method", "@target-uri"}
+		for _, h := range []string{"content-digest", "content-type"} {
+			if _, ok := req.Header[http.CanonicalHeaderKey(h)]; ok {
+				components = append(components, h)
+			}
+		}
+	}
+
+	created := s.now()
+	params := []sfParam{{key: "created", value: created.Unix()}}
+	if s.expires > 0 {
+		params = append(params, sfParam{key: "expires", value: created.Add(s.expires).Unix()})
+	}
+	if s.nonce != nil {
+		params = append(params, sfParam{key: "nonce", value: s.nonce()})
+	}
+	if id := s.key.KeyID(); id != "" {
+		params = append(params, sfParam{key: "keyid", value: id})
+	}
+	params = append(params, sfParam{key: "alg", value: s.key.Algorithm()})
+	if s.tag != "" {
+		params = append(params, sfParam{key: "tag", value: s.tag})
+	}
+
+	items := make([]sfItem, 0, len(components))
+	for _, c := range components {
+		items = append(items, sfItem{value: strings.ToLower(c)})
+	}
+
+	sigParams := sfSerializeInnerList(items, params)
+	base, err := signatureBase(signatureMessage{req: req}, items, sigParams)
+	if err != nil {
+		return &MessageSignatureError{Label: s.label, Err: err}
+	}
+	sig, err := s.key.Sign(base)
+	if err != nil {
+		return &MessageSignatureError{Label: s.label, Err: err}
+	}
+
+	req.Header.Set("Signature-Input", s.label+"="+sigParams)
+	req.Header.Set("Signature", s.label+"="+sfSerializeBareItem(sig))
+	return nil
+}
+
+var _ ResponseVerifier = (*MessageVerifier)(nil)
+
+type MessageVerifier struct {
+	keys     map[string]SignatureKey
+	label    string
+	required []string
+	maxAge   time.Duration
+	now      func() time.Time
+}
+
+// NewMessageVerifier looks up keys by the 'keyid' parameter,
+// a signature without 'keyid' is accepted when exactly one key is given.
+func NewMessageVerifier(keys ...SignatureKey) *MessageVerifier {
+	v := &MessageVerifier{
+		keys: make(map[string]SignatureKey, len(keys)),
+		now:  time.Now,
+	}
+	for _, k := range keys {
+		v.keys[k.KeyID()] = k
+	}
+	return v
+}
+
+// WithLabel only verifies the signature with label, otherwise any valid signature is accepted.
+func (v *MessageVerifier) WithLabel(label string) *MessageVerifier {
+	v.label = label
+	return v
+}
+
+func (v *MessageVerifier) WithRequiredComponents(components ...string) *MessageVerifier {
+	v.required = make([]string, 0, len(components))
+	for _, c := range components {
+		v.required = append(v.required, strings.ToLower(c))
+	}
+	return v
+}
+
+// WithMaxAge rejects signatures whose 'created' parameter is older than d, or missing.
+func (v *MessageVerifier) WithMaxAge(d time.Duration) *MessageVerifier {
+	v.maxAge = d
+	return v
+}
+
+func (v *MessageVerifier) WithClock(now func() time.Time) *MessageVerifier {
+	v.now = now
+	return v
+}
+
+// VerifyResponse verifies the signature of resp, if it covers 'content-digest',
+// the digest is verified against body so that the body is authenticated too.
+func (v *MessageVerifier) VerifyResponse(resp *http.Response, body []byte) error {
+	msg := signatureMessage{req: resp.Request, resp: resp}
+
+	inputs, err := sfParseDictionary(strings.Join(resp.Header.Values("Signature-Input"), ", "))
+	if err != nil {
+		return &MessageSignatureError{Err: err}
+	}
+	signatures, err := sfParseDictionary(strings.Join(resp.Header.Values("Signature"), ", "))
+	if err != nil {
+		return &MessageSignatureError{Err: err}
+	}
+
+	err = &MessageSignatureError{Label: v.label, Err: ErrSignatureMissing}
+	for _, input := range inputs {
+		if v.label != "" && input.key != v.label {
+			continue
+		}
+		if err = v.verify(msg, input, signatures, body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (v *MessageVerifier) verify(msg signatureMessage, input sfMember, signatures []sfMember, body []byte) error {
+	fail := func(err error) error {
+		return &MessageSignatureError{Label: input.key, Err: err}
+	}
+
+	if !input.isInner {
+		return fail(errors.New("malformed 'Signature-Input'"))
+	}
+	var sig []byte
+	for _, m := range signatures {
+		if m.key == input.key {
+			sig, _ = m.item.value.([]byte)
+		}
+	}
+	if sig == nil {
+		return fail(ErrSignatureMissing)
+	}
+
+	key, err := v.lookupKey(input.item)
+	if err != nil {
+		return fail(err)
+	}
+
+	now := v.now()
+	if v.maxAge > 0 {
+		created, ok := input.item.param("created")
+		if !ok {
+			return fail(fmt.Errorf("%w: missing 'created'", ErrSignatureExpired))
+		}
+		if ts, _ := created.(int64); now.Sub(time.Unix(ts, 0)) > v.maxAge {
+			return fail(ErrSignatureExpired)
+		}
+	}
+	if expires, ok := input.item.param("expires"); ok {
+		if ts, _ := expires.(int64); now.After(time.Unix(ts, 0)) {
+			return fail(ErrSignatureExpired)
+		}
+	}
+
+	for _, r := range v.required {
+		covered := false
+		for _, it := range input.inner {
+			if name, _ := it.value.(string); name == r {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return fail(fmt.Errorf("%w: %q", ErrSignatureCoverage, r))
+		}
+	}
+
+	base, err := signatureBase(msg, input.inner, sfSerializeInnerList(input.inner, input.item.params))
+	if err != nil {
+		return fail(err)
+	}
+	if err = key.Verify(base, sig); err != nil {
+		return fail(err)
+	}
+
+	for _, it := range input.inner {
+		if name, _ := it.value.(string); name != "content-digest" {
+			continue
+		}
+		if err = verifyCoveredDigest(msg.resp, body); err != nil {
+			return fail(err)
+		}
+	}
+	return nil
+}
+
+// verifyCoveredDigest verifies the 'Content-Digest' covered by a signature against body.
+func verifyCoveredDigest(resp *http.Response, body []byte) error {
+	if resp.Uncompressed {
+		return &DigestError{Field: "Content-Digest", Err: errors.New("response is decompressed")}
+	}
+	ok, err := verifyDigestField(resp.Header, "Content-Digest", body)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &DigestError{Field: "Content-Digest", Err: ErrDigestMissing}
+	}
+	return nil
+}
+
+func (v *MessageVerifier) lookupKey(params sfItem) (SignatureKey, error) {
+	var key SignatureKey
+	if id, ok := params.param("keyid"); ok {
+		s, _ := id.(string)
+		if key, ok = v.keys[s]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrSignatureKeyUnknown, s)
+		}
+	} else if len(v.keys) == 1 {
+		for _, k := range v.keys {
+			key = k
+		}
+	} else {
+		return nil, ErrSignatureKeyUnknown
+	}
+
+	if alg, ok := params.param("alg"); ok {
+		if s, _ := alg.(string); s != key.Algorithm() {
+			return nil, fmt.Errorf("algorithm %q does not match the key (%s)", s, key.Algorithm())
+		}
+	}
+	return key, nil
+}
+
+type signatureMessage struct {
+	req  *http.Request
+	resp *http.Response
+}
+
+func (m signatureMessage) header() http.Header {
+	if m.resp != nil {
+		return m.resp.Header
+	}
+	return m.req.Header
+}
+
+// See RFC 9421 section 2.5
+func signatureBase(msg signatureMessage, components []sfItem, sigParams string) ([]byte, error) {
+	var sb strings.Builder
+	seen := make(map[string]bool, len(components))
+	for _, c := range components {
+		name, ok := c.value.(string)
+		if !ok {
+			return nil, errors.New("component identifier must be a string")
+		}
+		if len(c.params) != 0 {
+			return nil, fmt.Errorf("component %q: parameters are not supported", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("component %q: duplicated", name)
+		}
+		seen[name] = true
+
+		value, err := msg.component(name)
+		if err != nil {
+			return nil, err
+		}
+		sb.WriteString(sfSerializeBareItem(name))
+		sb.WriteString(": ")
+		sb.WriteString(value)
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(`"@signature-params": `)
+	sb.WriteString(sigParams)
+	return []byte(sb.String()), nil
+}
+
+func (m signatureMessage) component(name string) (string, error) {
+	if !strings.HasPrefix(name, "@") {
+		vv, ok := m.header()[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return "", fmt.Errorf("component %q: missing header", name)
+		}
+		values := make([]string, 0, len(vv))
+		for _, v := range vv {
+			values = append(values, strings.TrimSpace(v))
+		}
+		return strings.Join(values, ", "), nil
+	}
+
+	if name == "@status" {
+		if m.resp == nil {
+			return "", fmt.Errorf("component %q: not a response", name)
+		}
+		return strconv.Itoa(m.resp.StatusCode), nil
+	}
+
+	if m.resp != nil || m.req == nil {
+		return "", fmt.Errorf("component %q: request components of a response are not supported", name)
+	}
+	u := m.req.URL
+	switch name {
+	case "@method":
+		return m.req.Method, nil
+	case "@target-uri":
+		return u.String(), nil
+	case "@authority":
+		host := m.req.Host
+		if host == "" {
+			host = u.Host
+		}
+		return strings.ToLower(host), nil
+	case "@scheme":
+		return strings.ToLower(u.Scheme), nil
+	case "@request-target":
+		return u.RequestURI(), nil
+	case "@path":
+		if p := u.EscapedPath(); p != "" {
+			return p, nil
+		}
+		return "/", nil
+	case "@query":
+		return "?" + u.RawQuery, nil
+	}
+	return "", fmt.Errorf("component %q: unsupported", name)
+}
@@ -0,0 +1,243 @@
+package xhttpclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testRoundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (fn testRoundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func TestMessageSigner_SignRequest(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := NewSignatureKeyECDSA("test-key-ecc-p256", ecPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		signer     *MessageSigner
+		wantParams string
+	}{
+		{
+			name:       "ed25519",
+			signer:     NewMessageSigner(NewSignatureKeyEd25519("test-key-ed25519", edPriv)),
+			wantParams: `("@method" "@target-uri" "content-type");created=1618884473;keyid="test-key-ed25519";alg="ed25519"`,
+		},
+		{
+			name:       "ecdsa",
+			signer:     NewMessageSigner(ecKey, "@method", "@authority", "@path", "@query").WithLabel("sig-b").WithExpires(time.Minute),
+			wantParams: `("@method" "@authority" "@path" "@query");created=1618884473;expires=1618884533;keyid="test-key-ecc-p256";alg="ecdsa-p256-sha256"`,
+		},
+		{
+			name:       "hmac",
+			signer:     NewMessageSigner(NewSignatureKeyHMACSHA256("test-shared-secret", []byte("secret")), "@method", "x-tenant").WithNonce(func() string { return "n1" }),
+			wantParams: `("@method" "x-tenant");created=1618884473;nonce="n1";keyid="test-shared-secret";alg="hmac-sha256"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var verifyErr error
+			cli := NewClient().
+				WithClient(&http.Client{Transport: testRoundTripFunc(func(req *http.Request) (*http.Response, error) {
+					verifyErr = testVerifyRequestSignature(req, tt.signer.key, tt.signer.label, tt.wantParams)
+					return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+				})}).
+				WithRequestSigner(tt.signer.WithClock(func() time.Time { return time.Unix(1618884473, 0) }))
+
+			var successV any
+			_, _, err := cli.Do(&successV, nil,
+				NewPost().
+					Path("https://example.com/foo?param=Value&Pet=dog").
+					SetHeader("X-Tenant", " t1 ").
+					Body(map[string]string{"hello": "world"}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verifyErr != nil {
+				t.Fatal(verifyErr)
+			}
+		})
+	}
+}
+
+func testVerifyRequestSignature(req *http.Request, key SignatureKey, label, wantParams string) error {
+	inputs, err := sfParseDictionary(req.Header.Get("Signature-Input"))
+	if err != nil {
+		return err
+	}
+	signatures, err := sfParseDictionary(req.Header.Get("Signature"))
+	if err != nil {
+		return err
+	}
+	if len(inputs) != 1 || inputs[0].key != label || len(signatures) != 1 || signatures[0].key != label {
+		return errors.New("unexpected labels: " + req.Header.Get("Signature-Input"))
+	}
+	if got := sfSerializeInnerList(inputs[0].inner, inputs[0].item.params); got != wantParams {
+		return errors.New("signature params = " + got + ", want " + wantParams)
+	}
+
+	base, err := signatureBase(signatureMessage{req: req}, inputs[0].inner, wantParams)
+	if err != nil {
+		return err
+	}
+	return key.Verify(base, signatures[0].item.value.([]byte))
+}
+
+func TestMessageVerifier_VerifyResponse(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverKey := NewSignatureKeyEd25519("server", priv)
+	now := time.Unix(1618884473, 0)
+
+	// sign adds the input later as a duplicate of the signature label
+	sign := func(w http.ResponseWriter, status int, created time.Time, tamper bool, later string) {
+		sigParams := `("@status" "content-type");created=` + strconv.FormatInt(created.Unix(), 10) + `;keyid="server"`
+		resp := &http.Response{StatusCode: status, Header: w.Header()}
+		items := []sfItem{{value: "@status"}, {value: "content-type"}}
+		base, err := signatureBase(signatureMessage{resp: resp}, items, sigParams)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sig, _ := serverKey.Sign(base)
+		if tamper {
+			sig[0] ^= 0xff
+		}
+		w.Header().Set("Signature-Input", "sig1="+sigParams)
+		if later != "" {
+			w.Header().Add("Signature-Input", "sig1="+later)
+		}
+		w.Header().Set("Signature", "sig1="+sfSerializeBareItem(sig))
+		w.WriteHeader(status)
+		io.WriteString(w, `{"hello":"world"}`)
+	}
+
+	// signDigest covers 'Content-Digest' of body, but sends sent
+	signDigest := func(w http.ResponseWriter, body, sent, params string) {
+		sum := sha256.Sum256([]byte(body))
+		w.Header().Set("Content-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+		sigParams := `("@status" "content-digest")` + params + `;keyid="server"`
+		resp := &http.Response{StatusCode: http.StatusOK, Header: w.Header()}
+		base, err := signatureBase(signatureMessage{resp: resp}, []sfItem{{value: "@status"}, {value: "content-digest"}}, sigParams)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sig, _ := serverKey.Sign(base)
+		w.Header().Set("Signature-Input", "sig1="+sigParams)
+		w.Header().Set("Signature", "sig1="+sfSerializeBareItem(sig))
+		io.WriteString(w, sent)
+	}
+
+	ts := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", ContentTypeValueJSON)
+			created := ";created=" + strconv.FormatInt(now.Unix(), 10)
+			switch r.URL.Path {
+			case "/digest":
+				signDigest(w, `{"hello":"world"}`, `{"hello":"world"}`, created)
+			case "/digest-tampered":
+				signDigest(w, `{"hello":"world"}`, `{"hello":"tampered"}`, created)
+			case "/no-created":
+				signDigest(w, `{"hello":"world"}`, `{"hello":"world"}`, "")
+			case "/ok":
+				sign(w, http.StatusOK, now, false, "")
+			case "/tampered":
+				sign(w, http.StatusOK, now, true, "")
+			case "/expired":
+				sign(w, http.StatusOK, now.Add(-time.Hour), false, "")
+			case "/duplicate":
+				sign(w, http.StatusOK, now, false, `("@status");keyid="server"`)
+			default:
+				io.WriteString(w, `{}`)
+			}
+		}),
+	)
+	defer ts.Close()
+
+	tests := []struct {
+		path     string
+		verifier *MessageVerifier
+		wantErr  error
+	}{
+		{path: "/ok", verifier: NewMessageVerifier(NewSignatureKeyEd25519Public("server", priv.Public().(ed25519.PublicKey)))},
+		{path: "/ok", verifier: NewMessageVerifier(serverKey).WithRequiredComponents("@status", "Content-Type")},
+		{path: "/ok", verifier: NewMessageVerifier(serverKey).WithRequiredComponents("content-digest"), wantErr: ErrSignatureCoverage},
+		{path: "/ok", verifier: NewMessageVerifier(NewSignatureKeyEd25519("other", priv)), wantErr: ErrSignatureKeyUnknown},
+		{path: "/ok", verifier: NewMessageVerifier(serverKey).WithLabel("sig2"), wantErr: ErrSignatureMissing},
+		{path: "/tampered", verifier: NewMessageVerifier(serverKey), wantErr: ErrSignatureInvalid},
+		{path: "/duplicate", verifier: NewMessageVerifier(serverKey), wantErr: ErrSignatureInvalid},
+		{path: "/expired", verifier: NewMessageVerifier(serverKey).WithMaxAge(time.Minute), wantErr: ErrSignatureExpired},
+		{path: "/unsigned", verifier: NewMessageVerifier(serverKey), wantErr: ErrSignatureMissing},
+		{path: "/digest", verifier: NewMessageVerifier(serverKey).WithMaxAge(time.Minute)},
+		{path: "/digest-tampered", verifier: NewMessageVerifier(serverKey), wantErr: ErrDigestMismatch},
+		{path: "/no-created", verifier: NewMessageVerifier(serverKey)},
+		{path: "/no-created", verifier: NewMessageVerifier(serverKey).WithMaxAge(time.Minute), wantErr: ErrSignatureExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			cli := NewClient().BaseURL(ts.URL).WithResponseVerifier(tt.verifier.WithClock(func() time.Time { return now }))
+
+			var successV map[string]string
+			_, _, err := cli.Do(&successV, nil, NewGet().Path(tt.path))
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if successV["hello"] != "world" {
+					t.Fatalf("successV = %v", successV)
+				}
+				return
+			}
+
+			var sigErr *MessageSignatureError
+			if !errors.As(err, &sigErr) || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if successV != nil {
+				t.Fatalf("successV = %v, want nil", successV)
+			}
+		})
+	}
+}
+
+func TestSfParseDictionary_duplicateKey(t *testing.T) {
+	members, err := sfParseDictionary(`a=1, b=2;x=1;x=3, a=(c)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 2 || members[0].key != "a" || !members[0].isInner || members[1].key != "b" {
+		t.Fatalf("members = %+v", members)
+	}
+	if x, _ := members[1].item.param("x"); len(members[1].item.params) != 1 || x != int64(3) {
+		t.Fatalf("params = %+v", members[1].item.params)
+	}
+}
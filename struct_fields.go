@@ -0,0 +1,367 @@
+package xhttpclient
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structField is an exported field tagged with `tagKey:"name,opt1,opt2"`, the untagged fields are skipped.
+// The untagged embedded structs are flattened, `tagKey:"-"` skips the field.
+type structField struct {
+	index []int
+	name  string
+	opts  []string
+	// layout is the `layout:"..."` tag of time.Time fields.
+	layout string
+}
+
+func (f structField) hasOpt(opt string) bool {
+	for _, o := range f.opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+type structFieldsKey struct {
+	t      reflect.Type
+	tagKey string
+}
+
+var _structFieldsCache sync.Map // structFieldsKey -> []structField
+
+func cachedStructFields(t reflect.Type, tagKey string) []structField {
+	key := structFieldsKey{t: t, tagKey: tagKey}
+	if fields, ok := _structFieldsCache.Load(key); ok {
+		return fields.([]structField)
+	}
+	fields, _ := _structFieldsCache.LoadOrStore(key, typeStructFields(t, tagKey, nil, nil))
+	return fields.([]structField)
+}
+
+// typeStructFields lists the fields of t, embedded at index of the structs of visiting,
+// an embedded struct already being visited, e.g. a pointer to itself, is skipped.
+func typeStructFields(t reflect.Type, tagKey string, index []int, visiting []reflect.Type) []structField {
+	visiting = append(visiting, t)
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup(tagKey)
+		if tag == "-" {
+			continue
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isScalarStruct(ft) {
+				if !containsType(visiting, ft) {
+					fields = append(fields, typeStructFields(ft, tagKey, fieldIndex, visiting)...)
+				}
+				continue
+			}
+		}
+		if !tagged || !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := structField{index: fieldIndex, name: name, layout: sf.Tag.Get("layout")}
+		if opts != "" {
+			f.opts = strings.Split(opts, ",")
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+func containsType(types []reflect.Type, t reflect.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	_timeType            = reflect.TypeOf(time.Time{})
+	_durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// isScalarStruct reports whether the struct t is formatted as a single value rather than flattened.
+func isScalarStruct(t reflect.Type) bool {
+	if t == _timeType {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return t.Implements(_textMarshalerType) || pt.Implements(_textMarshalerType) || pt.Implements(_textUnmarshalerType)
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, it returns false for a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// settableFieldByIndex is fieldByIndex allocating the nil embedded pointers, it returns false if one is not settable.
+func settableFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
+
+// formatValue formats a scalar: strings, bools, numbers, time.Time (RFC 3339, the layout or the 'unix' and
+// 'unixmilli' options), time.Duration and encoding.TextMarshaler.
+func formatValue(v reflect.Value, f structField) (string, error) {
+	if v.Type() == _timeType {
+		t := v.Interface().(time.Time)
+		switch {
+		case f.hasOpt("unix"):
+			return strconv.FormatInt(t.Unix(), 10), nil
+		case f.hasOpt("unixmilli"):
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		case f.layout != "":
+			return t.Format(f.layout), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	if v.Type() == _durationType {
+		return v.Interface().(time.Duration).String(), nil
+	}
+	if v.Type().Implements(_textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(_textMarshalerType) {
+		b, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// formatValues formats v as a scalar, or the elements of a slice or array, nil pointers are omitted.
+func formatValues(v reflect.Value, f structField) ([]string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !v.Type().Implements(_textMarshalerType) {
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte
+			return []string{string(v.Bytes())}, nil
+		}
+		ret := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			vv, err := formatValues(v.Index(i), f)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, vv...)
+		}
+		return ret, nil
+	}
+
+	s, err := formatValue(v, f)
+	if err != nil {
+		return nil, err
+	}
+	return []string{s}, nil
+}
+
+// structValue dereferences v, it must be a struct.
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("nil %s", reflect.TypeOf(v))
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%T is not a struct", v)
+	}
+	return rv, nil
+}
+
+// parseValue parses s into the scalar v, the reverse of formatValue.
+// time.Time also accepts the HTTP dates, see http.ParseTime, and time.Duration also accepts the seconds.
+func parseValue(s string, v reflect.Value, f structField) error {
+	if v.Type() == _timeType {
+		t, err := parseTime(s, f)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.Type() == _durationType {
+		d, err := parseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(_textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+		return nil
+	}
+	return fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// parseDuration parses s like "1m30s", the seconds like "90" or an HTTP date as the duration until the date,
+// which is zero for a past date.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err == nil {
+		return d, nil
+	}
+	if seconds, serr := strconv.ParseInt(s, 10, 64); serr == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	if t, terr := http.ParseTime(s); terr == nil {
+		return max(time.Until(t), 0), nil
+	}
+	return 0, err
+}
+
+func parseTime(s string, f structField) (time.Time, error) {
+	switch {
+	case f.hasOpt("unix"), f.hasOpt("unixmilli"):
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if f.hasOpt("unix") {
+			return time.Unix(n, 0), nil
+		}
+		return time.UnixMilli(n), nil
+	case f.layout != "":
+		return time.Parse(f.layout, s)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if ht, herr := http.ParseTime(s); herr == nil {
+			return ht, nil
+		}
+	}
+	return t, err
+}
+
+// parseValues parses ss into v, the elements of a slice or the first value of a scalar,
+// the nil pointers are allocated.
+func parseValues(ss []string, v reflect.Value, f structField) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return parseValues(ss, v.Elem(), f)
+	}
+
+	if v.Kind() == reflect.Slice && !reflect.PointerTo(v.Type()).Implements(_textUnmarshalerType) {
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// []byte
+			v.SetBytes([]byte(ss[0]))
+			return nil
+		}
+		slice := reflect.MakeSlice(v.Type(), len(ss), len(ss))
+		for i, s := range ss {
+			if err := parseValues([]string{s}, slice.Index(i), f); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+
+	return parseValue(ss[0], v, f)
+}
@@ -0,0 +1,330 @@
+package xhttpclient
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A minimal subset of RFC 8941 (Structured Field Values for HTTP),
+// enough for 'Signature-Input', 'Signature' and 'Content-Digest'.
+
+type sfToken string
+
+type sfParam struct {
+	key   string
+	value any
+}
+
+type sfItem struct {
+	value  any // string, sfToken, int64, []byte or bool
+	params []sfParam
+}
+
+type sfMember struct {
+	key     string
+	item    sfItem
+	inner   []sfItem
+	isInner bool
+}
+
+func (it sfItem) param(key string) (any, bool) {
+	for _, p := range it.params {
+		if p.key == key {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+func sfParseDictionary(s string) (members []sfMember, err error) {
+	p := &sfParser{s: s}
+	p.skipSP()
+	for !p.eof() {
+		var m sfMember
+		if m.key, err = p.parseKey(); err != nil {
+			return nil, err
+		}
+		if p.peek() == '=' {
+			p.i++
+			if p.peek() == '(' {
+				m.isInner = true
+				if m.inner, m.item.params, err = p.parseInnerList(); err != nil {
+					return nil, err
+				}
+			} else if m.item, err = p.parseItem(); err != nil {
+				return nil, err
+			}
+		} else {
+			m.item.value = true
+			if m.item.params, err = p.parseParams(); err != nil {
+				return nil, err
+			}
+		}
+		members = sfSetMember(members, m)
+
+		p.skipOWS()
+		if p.eof() {
+			break
+		}
+		if p.peek() != ',' {
+			return nil, fmt.Errorf("structured field: unexpected %q at %d", p.peek(), p.i)
+		}
+		p.i++
+		p.skipOWS()
+		if p.eof() {
+			return nil, errors.New("structured field: trailing comma")
+		}
+	}
+	return members, nil
+}
+
+type sfParser struct {
+	s string
+	i int
+}
+
+func (p *sfParser) eof() bool { return p.i >= len(p.s) }
+
+func (p *sfParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.s[p.i]
+}
+
+func (p *sfParser) skipSP() {
+	for !p.eof() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *sfParser) skipOWS() {
+	for !p.eof() && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+func (p *sfParser) parseKey() (string, error) {
+	start := p.i
+	if c := p.peek(); !(c >= 'a' && c <= 'z' || c == '*') {
+		return "", fmt.Errorf("structured field: invalid key at %d", p.i)
+	}
+	for !p.eof() {
+		c := p.s[p.i]
+		if c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.' || c == '*' {
+			p.i++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.i], nil
+}
+
+func (p *sfParser) parseInnerList() (items []sfItem, params []sfParam, err error) {
+	p.i++ // '('
+	for {
+		p.skipSP()
+		if p.eof() {
+			return nil, nil, errors.New("structured field: unterminated inner list")
+		}
+		if p.peek() == ')' {
+			p.i++
+			params, err = p.parseParams()
+			return items, params, err
+		}
+		it, err := p.parseItem()
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, it)
+		if c := p.peek(); c != ' ' && c != ')' {
+			return nil, nil, fmt.Errorf("structured field: unexpected %q at %d", c, p.i)
+		}
+	}
+}
+
+func (p *sfParser) parseItem() (it sfItem, err error) {
+	if it.value, err = p.parseBareItem(); err != nil {
+		return it, err
+	}
+	it.params, err = p.parseParams()
+	return it, err
+}
+
+func (p *sfParser) parseParams() (params []sfParam, err error) {
+	for p.peek() == ';' {
+		p.i++
+		p.skipSP()
+		var param sfParam
+		if param.key, err = p.parseKey(); err != nil {
+			return nil, err
+		}
+		param.value = true
+		if p.peek() == '=' {
+			p.i++
+			if param.value, err = p.parseBareItem(); err != nil {
+				return nil, err
+			}
+		}
+		params = sfSetParam(params, param)
+	}
+	return params, nil
+}
+
+// sfSetMember overwrites the value of a duplicate key in place, see RFC 8941 section 4.2.2.
+func sfSetMember(members []sfMember, m sfMember) []sfMember {
+	for i := range members {
+		if members[i].key == m.key {
+			members[i] = m
+			return members
+		}
+	}
+	return append(members, m)
+}
+
+// sfSetParam is sfSetMember for parameters, see RFC 8941 section 4.2.3.2.
+func sfSetParam(params []sfParam, param sfParam) []sfParam {
+	for i := range params {
+		if params[i].key == param.key {
+			params[i] = param
+			return params
+		}
+	}
+	return append(params, param)
+}
+
+func (p *sfParser) parseBareItem() (any, error) {
+	c := p.peek()
+	switch {
+	case c == '"':
+		return p.parseString()
+	case c == ':':
+		return p.parseByteSequence()
+	case c == '?':
+		p.i++
+		switch p.peek() {
+		case '1':
+			p.i++
+			return true, nil
+		case '0':
+			p.i++
+			return false, nil
+		}
+		return nil, fmt.Errorf("structured field: invalid boolean at %d", p.i)
+	case c == '-' || c >= '0' && c <= '9':
+		return p.parseInteger()
+	case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '*':
+		start := p.i
+		for !p.eof() && !strings.ContainsRune(" \t,;()=\"", rune(p.s[p.i])) {
+			p.i++
+		}
+		return sfToken(p.s[start:p.i]), nil
+	}
+	return nil, fmt.Errorf("structured field: unexpected %q at %d", c, p.i)
+}
+
+func (p *sfParser) parseString() (string, error) {
+	p.i++ // '"'
+	var sb strings.Builder
+	for !p.eof() {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '\\':
+			if p.eof() || (p.s[p.i] != '"' && p.s[p.i] != '\\') {
+				return "", errors.New("structured field: invalid escape")
+			}
+			sb.WriteByte(p.s[p.i])
+			p.i++
+		case '"':
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", errors.New("structured field: unterminated string")
+}
+
+func (p *sfParser) parseByteSequence() ([]byte, error) {
+	p.i++ // ':'
+	end := strings.IndexByte(p.s[p.i:], ':')
+	if end < 0 {
+		return nil, errors.New("structured field: unterminated byte sequence")
+	}
+	b, err := base64.StdEncoding.DecodeString(p.s[p.i : p.i+end])
+	if err != nil {
+		return nil, fmt.Errorf("structured field: %w", err)
+	}
+	p.i += end + 1
+	return b, nil
+}
+
+func (p *sfParser) parseInteger() (int64, error) {
+	start := p.i
+	if p.peek() == '-' {
+		p.i++
+	}
+	for !p.eof() && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+		p.i++
+	}
+	if p.peek() == '.' {
+		return 0, errors.New("structured field: decimals are not supported")
+	}
+	return strconv.ParseInt(p.s[start:p.i], 10, 64)
+}
+
+func sfSerializeInnerList(items []sfItem, params []sfParam) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, it := range items {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(sfSerializeItem(it))
+	}
+	sb.WriteByte(')')
+	sb.WriteString(sfSerializeParams(params))
+	return sb.String()
+}
+
+func sfSerializeItem(it sfItem) string {
+	return sfSerializeBareItem(it.value) + sfSerializeParams(it.params)
+}
+
+func sfSerializeParams(params []sfParam) string {
+	var sb strings.Builder
+	for _, p := range params {
+		sb.WriteByte(';')
+		sb.WriteString(p.key)
+		if b, ok := p.value.(bool); ok && b {
+			continue
+		}
+		sb.WriteByte('=')
+		sb.WriteString(sfSerializeBareItem(p.value))
+	}
+	return sb.String()
+}
+
+var _sfStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func sfSerializeBareItem(v any) string {
+	switch tv := v.(type) {
+	case string:
+		return `"` + _sfStringEscaper.Replace(tv) + `"`
+	case sfToken:
+		return string(tv)
+	case int64:
+		return strconv.FormatInt(tv, 10)
+	case []byte:
+		return ":" + base64.StdEncoding.EncodeToString(tv) + ":"
+	case bool:
+		if tv {
+			return "?1"
+		}
+		return "?0"
+	}
+	panic(fmt.Sprintf("structured field: unsupported type '%T'", v))
+}
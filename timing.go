@@ -0,0 +1,172 @@
+package xhttpclient
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing is the breakdown of a round trip collected with net/http/httptrace,
+// the phases not involved (e.g. DNS and Connect of a reused connection) are zero.
+// Following a redirect, the phases are of the last request.
+type Timing struct {
+	Start time.Time
+
+	// Blocked is the time spent waiting for a connection, excluding DNS, Connect and TLSHandshake.
+	Blocked      time.Duration
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	// Send is the time spent writing the request after the connection is obtained.
+	Send time.Duration
+	// Wait is the time from the request written to the first response byte.
+	Wait time.Duration
+	// TimeToFirstByte is the time from Start to the first response byte.
+	TimeToFirstByte time.Duration
+	// BodyRead is the time from the first response byte to the body read to EOF or closed.
+	BodyRead time.Duration
+	Total    time.Duration
+
+	ConnReused  bool
+	ConnWasIdle bool
+	ConnIdle    time.Duration
+	RemoteAddr  string
+	LocalAddr   string
+}
+
+// WithTiming calls fn with the Timing of every request once the response body is read to EOF or closed,
+// or right after the round trip if it fails.
+func (xc *XClient) WithTiming(fn func(req *http.Request, t Timing)) *XClient {
+	xc.onTiming = fn
+	return xc
+}
+
+// OnTiming calls fn with the Timing of this request, see XClient.WithTiming.
+func (xr *XRequestBuilder) OnTiming(fn func(t Timing)) *XRequestBuilder {
+	xr.onTiming = fn
+	return xr
+}
+
+type timingTracer struct {
+	mu sync.Mutex
+
+	start                time.Time
+	getConn, gotConn     time.Time
+	dnsStart, dnsDone    time.Time
+	connStart, connDone  time.Time
+	tlsStart, tlsDone    time.Time
+	wroteRequest         time.Time
+	gotFirstResponseByte time.Time
+	done                 time.Time
+
+	connInfo httptrace.GotConnInfo
+}
+
+func (tt *timingTracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			tt.mu.Lock()
+			// a redirect starts over
+			tt.getConn = time.Now()
+			tt.dnsStart, tt.dnsDone = time.Time{}, time.Time{}
+			tt.connStart, tt.connDone = time.Time{}, time.Time{}
+			tt.tlsStart, tt.tlsDone = time.Time{}, time.Time{}
+			tt.mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tt.mu.Lock()
+			tt.gotConn = time.Now()
+			tt.connInfo = info
+			tt.mu.Unlock()
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { tt.mark(&tt.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { tt.mark(&tt.dnsDone) },
+		ConnectStart: func(string, string) {
+			tt.mu.Lock()
+			// the first of parallel dials (RFC 6555)
+			if tt.connStart.IsZero() {
+				tt.connStart = time.Now()
+			}
+			tt.mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				tt.mark(&tt.connDone)
+			}
+		},
+		TLSHandshakeStart:    func() { tt.mark(&tt.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { tt.mark(&tt.tlsDone) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { tt.mark(&tt.wroteRequest) },
+		GotFirstResponseByte: func() { tt.mark(&tt.gotFirstResponseByte) },
+	}
+}
+
+func (tt *timingTracer) finish() {
+	tt.mark(&tt.done)
+}
+
+func (tt *timingTracer) mark(t *time.Time) {
+	tt.mu.Lock()
+	*t = time.Now()
+	tt.mu.Unlock()
+}
+
+// report calls the timing callbacks once resp is read, or right away if the round trip failed.
+func (tt *timingTracer) report(req *http.Request, resp *http.Response, fns ...func(req *http.Request, t Timing)) {
+	finish := func() {
+		tt.finish()
+		t := tt.timing()
+		for _, fn := range fns {
+			if fn != nil {
+				fn(req, t)
+			}
+		}
+	}
+	if resp == nil {
+		finish()
+		return
+	}
+	resp.Body = &notifyBody{ReadCloser: resp.Body, finish: finish}
+}
+
+// timing returns the Timing collected so far.
+func (tt *timingTracer) timing() Timing {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	t := Timing{
+		Start:        tt.start,
+		DNS:          between(tt.dnsStart, tt.dnsDone),
+		Connect:      between(tt.connStart, tt.connDone),
+		TLSHandshake: between(tt.tlsStart, tt.tlsDone),
+		Send:         between(tt.gotConn, tt.wroteRequest),
+		Wait:         between(tt.wroteRequest, tt.gotFirstResponseByte),
+		ConnReused:   tt.connInfo.Reused,
+		ConnWasIdle:  tt.connInfo.WasIdle,
+		ConnIdle:     tt.connInfo.IdleTime,
+	}
+	if blocked := between(tt.getConn, tt.gotConn) - t.DNS - t.Connect - t.TLSHandshake; blocked > 0 {
+		t.Blocked = blocked
+	}
+	if conn := tt.connInfo.Conn; conn != nil {
+		t.RemoteAddr = conn.RemoteAddr().String()
+		t.LocalAddr = conn.LocalAddr().String()
+	}
+	if !tt.gotFirstResponseByte.IsZero() {
+		t.TimeToFirstByte = tt.gotFirstResponseByte.Sub(tt.start)
+		t.BodyRead = between(tt.gotFirstResponseByte, tt.done)
+	}
+	if !tt.done.IsZero() {
+		t.Total = tt.done.Sub(tt.start)
+	}
+	return t
+}
+
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
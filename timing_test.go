@@ -0,0 +1,107 @@
+package xhttpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestXClient_WithTiming(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, `{}`)
+	}))
+	defer ts.Close()
+
+	var timings []Timing
+	rec := NewHARRecorder()
+	cli := NewClient().
+		BaseURL(ts.URL).
+		WithClient(ts.Client()).
+		WithHARRecorder(rec).
+		WithTiming(func(req *http.Request, t Timing) {
+			timings = append(timings, t)
+		})
+
+	var successV map[string]any
+	for i := 0; i < 2; i++ {
+		if _, _, err := cli.Do(&successV, nil, NewGet().Path("a")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(timings) != 2 {
+		t.Fatalf("timings = %d, want 2", len(timings))
+	}
+
+	first, second := timings[0], timings[1]
+	if first.ConnReused || first.Connect <= 0 || first.TLSHandshake <= 0 {
+		t.Fatalf("first = %+v", first)
+	}
+	if first.RemoteAddr != ts.Listener.Addr().String() || first.LocalAddr == "" {
+		t.Fatalf("first.RemoteAddr = %q, LocalAddr = %q", first.RemoteAddr, first.LocalAddr)
+	}
+	if first.Wait < 10*time.Millisecond || first.TimeToFirstByte < first.Wait || first.Total < first.TimeToFirstByte {
+		t.Fatalf("first = %+v", first)
+	}
+	if !second.ConnReused || second.Connect != 0 || second.TLSHandshake != 0 {
+		t.Fatalf("second = %+v", second)
+	}
+
+	entries := rec.HAR().Log.Entries
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if tm := entries[0].Timings; tm.Connect <= 0 || tm.SSL <= 0 || tm.Connect < tm.SSL || tm.Wait < 10 {
+		t.Fatalf("entries[0].timings = %+v", tm)
+	}
+	if tm := entries[1].Timings; tm.Connect != -1 || tm.SSL != -1 || tm.DNS != -1 {
+		t.Fatalf("entries[1].timings = %+v", tm)
+	}
+	if entries[0].ServerIPAddress != "127.0.0.1" || entries[0].Connection != entries[1].Connection {
+		t.Fatalf("serverIPAddress = %q, connection = %q, %q", entries[0].ServerIPAddress, entries[0].Connection, entries[1].Connection)
+	}
+}
+
+func TestXRequestBuilder_OnTiming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.(http.Flusher).Flush()
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, "body")
+	}))
+	defer ts.Close()
+
+	var (
+		called bool
+		timing Timing
+	)
+	_, resp, cancel, err := NewClient().DoWithRaw(NewGet().Path(ts.URL).OnTiming(func(t Timing) {
+		called = true
+		timing = t
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	if called {
+		t.Fatal("called before the body is read")
+	}
+	io.ReadAll(resp.Body)
+	if !called {
+		t.Fatal("not called after the body is read")
+	}
+	if timing.BodyRead < 10*time.Millisecond || timing.Total < timing.TimeToFirstByte+timing.BodyRead {
+		t.Fatalf("timing = %+v", timing)
+	}
+
+	// failed round trip
+	called = false
+	_, _, _, err = NewClient().DoWithRaw(NewGet().Path("http://127.0.0.1:1").OnTiming(func(t Timing) {
+		called = true
+	}))
+	if err == nil || !called {
+		t.Fatalf("err = %v, called = %t", err, called)
+	}
+}
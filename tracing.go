@@ -0,0 +1,56 @@
+package xhttpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestTracer starts a span for every request sent by Do, DoOnceWithBodyCodec and DoWithRaw,
+// see the xhttpotel module for OpenTelemetry.
+type RequestTracer interface {
+	// Start is called once the client headers are merged, before the request is built.
+	// The returned context becomes the request context, the propagation fields are injected into header.
+	Start(ctx context.Context, method, route string, header http.Header) (context.Context, RequestSpan)
+}
+
+type RequestSpan interface {
+	// Request is called with the request right before it is sent.
+	Request(req *http.Request)
+	// CodecError is called with op "encode" if the request body fails to encode,
+	// or "decode" if the response body fails to decode.
+	CodecError(op string, err error)
+	// End is called once, with the response and the error returned by Do and DoOnceWithBodyCodec,
+	// or once the response body is read to EOF or closed with DoWithRaw.
+	// resp is nil if the request failed to build or send.
+	End(resp *http.Response, err error)
+}
+
+func (xc *XClient) WithRequestTracer(tracer RequestTracer) *XClient {
+	xc.tracer = tracer
+	return xc
+}
+
+// startSpan is called after initXReq.
+func (xc *XClient) startSpan(xReq *XRequestBuilder) RequestSpan {
+	if xc.tracer == nil {
+		return noopSpan{}
+	}
+
+	ctx := xReq.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if xReq.header == nil {
+		xReq.header = make(http.Header)
+	}
+	ctx, span := xc.tracer.Start(ctx, xReq.method, xReq.routeLabel(), xReq.header)
+	xReq.ctx = ctx
+	xReq.span = span
+	return span
+}
+
+type noopSpan struct{}
+
+func (noopSpan) Request(*http.Request)     {}
+func (noopSpan) CodecError(string, error)  {}
+func (noopSpan) End(*http.Response, error) {}
@@ -0,0 +1,84 @@
+package xhttpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+type testTracer struct {
+	started []string
+	spans   []*testSpan
+}
+
+type testSpan struct {
+	req         *http.Request
+	codecErrors []string
+	ended       int
+	endResp     *http.Response
+	endErr      error
+}
+
+type testTraceKey struct{}
+
+func (tr *testTracer) Start(ctx context.Context, method, route string, header http.Header) (context.Context, RequestSpan) {
+	tr.started = append(tr.started, method+" "+route)
+	header.Set("Traceparent", "00-trace-span-01")
+	s := new(testSpan)
+	tr.spans = append(tr.spans, s)
+	return context.WithValue(ctx, testTraceKey{}, s), s
+}
+
+func (s *testSpan) Request(req *http.Request)       { s.req = req }
+func (s *testSpan) CodecError(op string, err error) { s.codecErrors = append(s.codecErrors, op) }
+func (s *testSpan) End(resp *http.Response, err error) {
+	s.ended++
+	s.endResp, s.endErr = resp, err
+}
+
+func TestXClient_WithRequestTracer(t *testing.T) {
+	tracer := new(testTracer)
+	cli := NewClient().
+		BaseURL("https://api.example.com").
+		SetHeader("Traceparent", "client").
+		WithRequestTracer(tracer).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Header.Get("Traceparent"))
+		}))
+
+	var successV string
+	_, respBody, err := cli.Do(&successV, nil, NewGet().Path("users", "1").Route("/users/{id}"))
+	if err == nil {
+		t.Fatal("want decode error")
+	}
+	if string(respBody) != "00-trace-span-01" {
+		t.Fatalf("traceparent = %q, want the injected one", respBody)
+	}
+	s := tracer.spans[0]
+	if tracer.started[0] != "GET /users/{id}" || s.req == nil || s.req.Context().Value(testTraceKey{}) != s {
+		t.Fatalf("started = %v, span = %+v", tracer.started, s)
+	}
+	if s.ended != 1 || s.endResp == nil || s.endErr != err || len(s.codecErrors) != 1 || s.codecErrors[0] != "decode" {
+		t.Fatalf("span = %+v", s)
+	}
+
+	if _, _, err = cli.Do(&successV, nil, NewPost().Path("users").Body(make(chan int))); err == nil {
+		t.Fatal("want encode error")
+	}
+	if s = tracer.spans[1]; s.ended != 1 || s.endResp != nil || s.req != nil || len(s.codecErrors) != 1 || s.codecErrors[0] != "encode" {
+		t.Fatalf("span = %+v", s)
+	}
+
+	_, _, cancel, err := cli.DoWithRaw(NewGet().Path("raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s = tracer.spans[2]; s.ended != 0 {
+		t.Fatal("ended before the body is closed")
+	}
+	cancel()
+	if s.ended != 1 || s.endErr != nil {
+		t.Fatalf("span = %+v", s)
+	}
+}
@@ -0,0 +1,208 @@
+package xhttpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var _ http.RoundTripper = (*handlerTransport)(nil)
+
+// NewHandlerTransport returns a http.RoundTripper which serves requests in-process with handler,
+// without listening on any port.
+//
+// The response is returned as soon as the handler writes the header (or the first byte of the body),
+// the rest of the body is streamed through a pipe,
+// and the handler sees the cancellation of the request context.
+func NewHandlerTransport(handler http.Handler) http.RoundTripper {
+	return &handlerTransport{handler: handler}
+}
+
+// WithHandler makes the client serve requests with handler in-process, see NewHandlerTransport.
+func (xc *XClient) WithHandler(handler http.Handler) *XClient {
+	return xc.WithClient(&http.Client{Transport: NewHandlerTransport(handler)})
+}
+
+type handlerTransport struct {
+	handler http.Handler
+}
+
+func (t *handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := ctx.Err(); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	sreq := req.Clone(ctx)
+	sreq.RequestURI = req.URL.RequestURI()
+	sreq.RemoteAddr = "192.0.2.1:1234"
+	sreq.Proto, sreq.ProtoMajor, sreq.ProtoMinor = "HTTP/1.1", 1, 1
+	if sreq.Host == "" {
+		sreq.Host = req.URL.Host
+	}
+	if sreq.Body == nil {
+		sreq.Body = http.NoBody
+	}
+	if req.URL.Scheme == "https" {
+		sreq.TLS = &tls.ConnectionState{
+			Version:           tls.VersionTLS13,
+			HandshakeComplete: true,
+			ServerName:        req.URL.Hostname(),
+		}
+	}
+
+	pr, pw := io.Pipe()
+	rw := &handlerResponseWriter{
+		header:      make(http.Header),
+		discardBody: req.Method == http.MethodHead,
+		pw:          pw,
+		ready:       make(chan struct{}),
+	}
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		defer sreq.Body.Close()
+		defer func() {
+			if p := recover(); p != nil {
+				rw.finish(fmt.Errorf("handler panic: %v", p))
+			}
+		}()
+
+		t.handler.ServeHTTP(rw, sreq)
+		rw.finish(nil)
+	}()
+
+	select {
+	case <-rw.ready:
+	case <-ctx.Done():
+		pr.CloseWithError(ctx.Err())
+		return nil, ctx.Err()
+	}
+	if rw.err != nil {
+		return nil, rw.err
+	}
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			pr.CloseWithError(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	resp := &http.Response{
+		Status:        strconv.Itoa(rw.status) + " " + http.StatusText(rw.status),
+		StatusCode:    rw.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rw.sentHeader,
+		Body:          pr,
+		ContentLength: -1,
+		Request:       req,
+	}
+	if cl, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = cl
+	}
+	if rw.discardBody || !bodyAllowedForStatus(rw.status) {
+		resp.ContentLength = 0
+	}
+	return resp, nil
+}
+
+type handlerResponseWriter struct {
+	header      http.Header
+	sentHeader  http.Header
+	status      int
+	wroteHeader bool
+	discardBody bool
+
+	pw        *io.PipeWriter
+	ready     chan struct{}
+	readyOnce sync.Once
+	err       error
+}
+
+func (w *handlerResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *handlerResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	if code < 100 || code > 999 {
+		panic(fmt.Sprintf("invalid WriteHeader code %v", code))
+	}
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		// informational responses are not visible to http.Client
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = code
+	w.sentHeader = w.header.Clone()
+	w.readyOnce.Do(func() { close(w.ready) })
+}
+
+func (w *handlerResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.header.Get("Content-Type") == "" && w.header.Get("Transfer-Encoding") == "" {
+			w.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.discardBody {
+		return len(p), nil
+	}
+	if !bodyAllowedForStatus(w.status) {
+		return 0, http.ErrBodyNotAllowed
+	}
+	return w.pw.Write(p)
+}
+
+func (w *handlerResponseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (w *handlerResponseWriter) finish(err error) {
+	if err != nil {
+		// after the header is sent, the error is only visible while reading the body
+		w.readyOnce.Do(func() {
+			w.err = err
+			close(w.ready)
+		})
+		w.pw.CloseWithError(err)
+		return
+	}
+
+	if !w.wroteHeader {
+		if w.header.Get("Content-Length") == "" {
+			w.header.Set("Content-Length", "0")
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	w.pw.Close()
+}
+
+// Copied from net/http/transfer.go
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == 204:
+		return false
+	case status == 304:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,160 @@
+package xhttpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func BenchmarkXClient_Do_WithHandler(b *testing.B) {
+	cli := NewClient().WithHandler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	)
+	var tmpMsg any
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _, err := cli.Do(&tmpMsg, nil, NewGet().Path("http://example.com"))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.StopTimer()
+}
+
+func TestXClient_WithHandler(t *testing.T) {
+	type Echo struct {
+		Method string            `json:"method"`
+		URI    string            `json:"uri"`
+		Host   string            `json:"host"`
+		TLS    bool              `json:"tls"`
+		Header map[string]string `json:"header"`
+		Body   map[string]string `json:"body"`
+	}
+
+	cli := NewClient().
+		BaseURL("https://api.example.com/v1").
+		SetHeader("X-Client", "x").
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			echo := Echo{
+				Method: r.Method,
+				URI:    r.RequestURI,
+				Host:   r.Host,
+				TLS:    r.TLS != nil,
+				Header: map[string]string{
+					"X-Client":     r.Header.Get("X-Client"),
+					"X-Request":    r.Header.Get("X-Request"),
+					"Content-Type": r.Header.Get("Content-Type"),
+				},
+			}
+			if err := json.NewDecoder(r.Body).Decode(&echo.Body); err != nil {
+				t.Error(err)
+			}
+			w.Header().Set("X-Server", "s")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(echo)
+		}))
+
+	var successV Echo
+	resp, _, err := cli.Do(&successV, nil,
+		NewPost().
+			Path("users").
+			SetQuery("q", "1").
+			SetHeader("X-Request", "r").
+			Body(map[string]string{"hello": "world"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusCreated || resp.Header.Get("X-Server") != "s" {
+		t.Fatalf("resp = %d %v", resp.StatusCode, resp.Header)
+	}
+	want := Echo{
+		Method: http.MethodPost,
+		URI:    "/v1/users?q=1",
+		Host:   "api.example.com",
+		TLS:    true,
+		Header: map[string]string{"X-Client": "x", "X-Request": "r", "Content-Type": ContentTypeValueJSON},
+		Body:   map[string]string{"hello": "world"},
+	}
+	if got, _ := json.Marshal(successV); string(got) != string(mustMarshalJSON(want)) {
+		t.Fatalf("successV = %s, want %s", got, mustMarshalJSON(want))
+	}
+}
+
+func TestXClient_WithHandler_Streaming(t *testing.T) {
+	next := make(chan struct{})
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first\n")
+		w.(http.Flusher).Flush()
+		<-next
+		io.WriteString(w, "second\n")
+	}))
+
+	_, resp, cancel, err := cli.DoWithRaw(NewGet().Path("http://example.com/stream"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cancel()
+
+	br := bufio.NewReader(resp.Body)
+	if line, _ := br.ReadString('\n'); line != "first\n" {
+		t.Fatalf("line = %q, want %q", line, "first\n")
+	}
+	close(next)
+	if line, _ := br.ReadString('\n'); line != "second\n" {
+		t.Fatalf("line = %q, want %q", line, "second\n")
+	}
+	if _, err = br.ReadByte(); err != io.EOF {
+		t.Fatalf("err = %v, want EOF", err)
+	}
+}
+
+func TestXClient_WithHandler_Cancel(t *testing.T) {
+	handlerCtxErr := make(chan error, 1)
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		handlerCtxErr <- r.Context().Err()
+	}))
+
+	var successV any
+	_, _, err := cli.Do(&successV, nil, NewGet().Path("http://example.com").WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err = <-handlerCtxErr; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("handler ctx err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestXClient_WithHandler_Panic(t *testing.T) {
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	var successV any
+	_, _, err := cli.Do(&successV, nil, NewGet().Path("http://example.com"))
+	if err == nil || !strings.Contains(err.Error(), "handler panic: boom") {
+		t.Fatalf("err = %v, want handler panic", err)
+	}
+}
+
+func mustMarshalJSON(v any) []byte {
+	b, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,366 @@
+package xhttpclient
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	urlpkg "net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Validator validates the bodies against a contract, e.g. JSONSchemaValidator or OpenAPIValidator.
+type Validator interface {
+	// ValidateRequest validates the encoded request body before sending.
+	ValidateRequest(req *http.Request, body []byte) error
+	// ValidateResponse validates the response body before decoding.
+	ValidateResponse(resp *http.Response, body []byte) error
+}
+
+// WithValidator validates the encoded request bodies before sending and the response bodies before decoding,
+// the response bodies are only validated by Do and DoOnceWithBodyCodec since DoWithRaw leaves the body to the caller.
+// The errors wrap *ValidationError if the body does not satisfy the contract.
+func (xc *XClient) WithValidator(v Validator) *XClient {
+	xc.validator = v
+	return xc
+}
+
+// Validate validates this request with v instead of the Validator of the client, see XClient.WithValidator.
+func (xr *XRequestBuilder) Validate(v Validator) *XRequestBuilder {
+	xr.validator = v
+	return xr
+}
+
+func (xc *XClient) validatorOf(xReq *XRequestBuilder) Validator {
+	if xReq.validator != nil {
+		return xReq.validator
+	}
+	return xc.validator
+}
+
+// ValidatorNeedsRequestBody is implemented by the Validators which only validate some request bodies,
+// the body is read for ValidateRequest if NeedsRequestBody reports true, otherwise ValidateRequest gets a nil body.
+// Reading the body requires a replayable body, see http.Request.GetBody.
+type ValidatorNeedsRequestBody interface {
+	NeedsRequestBody(req *http.Request) bool
+}
+
+func validateRequest(v Validator, req *http.Request) error {
+	var body []byte
+	if nb, ok := v.(ValidatorNeedsRequestBody); !ok || nb.NeedsRequestBody(req) {
+		var err error
+		if body, err = readRequestBody(req); err != nil {
+			return fmt.Errorf("validate request: %w", err)
+		}
+	}
+	if err := v.ValidateRequest(req, body); err != nil {
+		return fmt.Errorf("validate request: %w", err)
+	}
+	return nil
+}
+
+// JSONSchemaValidator validates the JSON bodies against the schemas, a nil schema skips the body.
+type JSONSchemaValidator struct {
+	Request *JSONSchema
+	// Response validates the successful (2xx) responses.
+	Response *JSONSchema
+}
+
+func (v JSONSchemaValidator) NeedsRequestBody(req *http.Request) bool {
+	return v.Request != nil && isJSONContentType(req.Header.Get("Content-Type"))
+}
+
+func (v JSONSchemaValidator) ValidateRequest(req *http.Request, body []byte) error {
+	if v.Request == nil || len(body) == 0 || !isJSONContentType(req.Header.Get("Content-Type")) {
+		return nil
+	}
+	return v.Request.Validate(body)
+}
+
+func (v JSONSchemaValidator) ValidateResponse(resp *http.Response, body []byte) error {
+	if v.Response == nil || !ResponseIsSuccessfulGTE200LTE299(resp) || len(body) == 0 {
+		return nil
+	}
+	return v.Response.Validate(body)
+}
+
+// OpenAPIValidator validates the JSON bodies against the operations of an OpenAPI 3 document.
+//
+// The operation is matched by the method and the path, the request path must be the path template
+// prefixed by the base path of one of the servers of the operation, the path or the document, "/" without servers.
+// The variables of the server URLs match any segment.
+// The response is validated against the schema of its status code, a range like "4XX" or "default".
+// A request without a matching operation or a response with an undocumented status is a *ValidationError.
+type OpenAPIValidator struct {
+	operations []*openapiOperation
+}
+
+type openapiOperation struct {
+	method   string
+	bases    [][]string // the base paths of the servers, the variables are empty
+	segments []string   // the parameters are empty
+	literals int
+
+	request   *schemaNode
+	responses map[string]*openapiResponse
+}
+
+type openapiResponse struct {
+	schema *schemaNode // nil without a JSON schema
+}
+
+// NewOpenAPIValidator compiles the OpenAPI 3 document doc in JSON.
+func NewOpenAPIValidator(doc []byte) (*OpenAPIValidator, error) {
+	root, err := decodeJSONValue(doc)
+	if err != nil {
+		return nil, fmt.Errorf("decode openapi: %w", err)
+	}
+	spec, _ := root.(map[string]any)
+	if version, _ := spec["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version %q", version)
+	}
+
+	c := newSchemaCompiler(root)
+	v := new(OpenAPIValidator)
+	docBases := openapiServerBases(spec["servers"], [][]string{nil})
+	paths, _ := spec["paths"].(map[string]any)
+	for _, path := range sortedMapKeys(paths) {
+		item, _ := paths[path].(map[string]any)
+		itemBases := openapiServerBases(item["servers"], docBases)
+		for _, method := range sortedMapKeys(item) {
+			op, ok := item[method].(map[string]any)
+			if !ok || method == "parameters" || method == "servers" {
+				continue
+			}
+			ptr := "#/paths/" + escapeJSONPointer(path) + "/" + method
+			o, err := compileOpenAPIOperation(c, op, ptr)
+			if err != nil {
+				return nil, err
+			}
+			o.method = strings.ToUpper(method)
+			o.bases = openapiServerBases(op["servers"], itemBases)
+			o.segments, o.literals = templateSegments(path)
+			v.operations = append(v.operations, o)
+		}
+	}
+
+	if err = c.checkCycles(); err != nil {
+		return nil, err
+	}
+
+	// the more specific templates are matched first
+	sort.SliceStable(v.operations, func(i, j int) bool {
+		a, b := v.operations[i], v.operations[j]
+		if len(a.segments) != len(b.segments) {
+			return len(a.segments) > len(b.segments)
+		}
+		return a.literals > b.literals
+	})
+	return v, nil
+}
+
+func compileOpenAPIOperation(c *schemaCompiler, op map[string]any, ptr string) (*openapiOperation, error) {
+	o := &openapiOperation{responses: make(map[string]*openapiResponse)}
+
+	if rb, ok := op["requestBody"]; ok {
+		rbPtr, err := resolveOpenAPIRef(c, rb, ptr+"/requestBody")
+		if err != nil {
+			return nil, err
+		}
+		body, _ := lookupJSONPointer(c.doc, rbPtr)
+		if o.request, err = compileOpenAPIContent(c, body, rbPtr); err != nil {
+			return nil, err
+		}
+	}
+
+	responses, _ := op["responses"].(map[string]any)
+	for code, r := range responses {
+		rPtr, err := resolveOpenAPIRef(c, r, ptr+"/responses/"+escapeJSONPointer(code))
+		if err != nil {
+			return nil, err
+		}
+		resp, _ := lookupJSONPointer(c.doc, rPtr)
+		or := new(openapiResponse)
+		if or.schema, err = compileOpenAPIContent(c, resp, rPtr); err != nil {
+			return nil, err
+		}
+		o.responses[strings.ToUpper(code)] = or
+	}
+	return o, nil
+}
+
+// openapiServerBases returns the base paths of the Server Objects servers, or def without servers.
+func openapiServerBases(servers any, def [][]string) [][]string {
+	list, _ := servers.([]any)
+	var bases [][]string
+	for _, server := range list {
+		m, _ := server.(map[string]any)
+		u, ok := m["url"].(string)
+		if !ok {
+			continue
+		}
+		if _, rest, ok := strings.Cut(u, "://"); ok {
+			u = ""
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				u = rest[i:]
+			}
+		}
+		base, _ := templateSegments(u)
+		bases = append(bases, base)
+	}
+	if len(bases) == 0 {
+		return def
+	}
+	return bases
+}
+
+// templateSegments splits the path template, the segments with a variable are empty.
+func templateSegments(path string) (segments []string, literals int) {
+	for _, seg := range pathSegments(path) {
+		if strings.Contains(seg, "{") {
+			seg = ""
+		} else {
+			literals++
+		}
+		segments = append(segments, seg)
+	}
+	return segments, literals
+}
+
+func pathSegments(path string) []string {
+	if path = strings.Trim(path, "/"); path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// resolveOpenAPIRef returns the JSON pointer of v, following its $ref.
+func resolveOpenAPIRef(c *schemaCompiler, v any, ptr string) (string, error) {
+	for i := 0; i < 32; i++ {
+		m, _ := v.(map[string]any)
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return ptr, nil
+		}
+		var err error
+		if v, err = lookupJSONPointer(c.doc, ref); err != nil {
+			return "", fmt.Errorf("%s: %w", ptr, err)
+		}
+		ptr = ref
+	}
+	return "", fmt.Errorf("%s: too many references", ptr)
+}
+
+// compileOpenAPIContent compiles the JSON schema of the content of a request body or a response.
+func compileOpenAPIContent(c *schemaCompiler, v any, ptr string) (*schemaNode, error) {
+	m, _ := v.(map[string]any)
+	content, _ := m["content"].(map[string]any)
+	for _, mt := range sortedMapKeys(content) {
+		media, _ := content[mt].(map[string]any)
+		s, ok := media["schema"]
+		if !isJSONContentType(mt) || !ok {
+			continue
+		}
+		return c.compile(s, ptr+"/content/"+escapeJSONPointer(mt)+"/schema")
+	}
+	return nil, nil
+}
+
+func (v *OpenAPIValidator) NeedsRequestBody(req *http.Request) bool {
+	op, err := v.match(req)
+	return err == nil && op.request != nil && isJSONContentType(req.Header.Get("Content-Type"))
+}
+
+func (v *OpenAPIValidator) ValidateRequest(req *http.Request, body []byte) error {
+	op, err := v.match(req)
+	if err != nil {
+		return err
+	}
+	if op.request == nil || len(body) == 0 || !isJSONContentType(req.Header.Get("Content-Type")) {
+		return nil
+	}
+	return validateSchemaNode(op.request, body)
+}
+
+func (v *OpenAPIValidator) ValidateResponse(resp *http.Response, body []byte) error {
+	op, err := v.match(resp.Request)
+	if err != nil {
+		return err
+	}
+
+	code := strconv.Itoa(resp.StatusCode)
+	r, ok := op.responses[code]
+	if !ok {
+		r, ok = op.responses[code[:1]+"XX"]
+	}
+	if !ok {
+		r, ok = op.responses["DEFAULT"]
+	}
+	if !ok {
+		return &ValidationError{Violations: []Violation{{
+			Keyword: "responses",
+			Message: fmt.Sprintf("status %d is not documented", resp.StatusCode),
+		}}}
+	}
+	if r.schema == nil || len(body) == 0 || !isJSONContentType(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+	return validateSchemaNode(r.schema, body)
+}
+
+func (v *OpenAPIValidator) match(req *http.Request) (*openapiOperation, error) {
+	// the escaped path keeps a path param of "a/b" in a segment
+	segments := pathSegments(req.URL.EscapedPath())
+	for i, seg := range segments {
+		if seg, err := urlpkg.PathUnescape(seg); err == nil {
+			segments[i] = seg
+		}
+	}
+	for _, op := range v.operations {
+		if op.method != req.Method {
+			continue
+		}
+		for _, base := range op.bases {
+			if len(base)+len(op.segments) == len(segments) &&
+				matchSegments(base, segments[:len(base)]) && matchSegments(op.segments, segments[len(base):]) {
+				return op, nil
+			}
+		}
+	}
+	return nil, &ValidationError{Violations: []Violation{{
+		Keyword: "paths",
+		Message: fmt.Sprintf("no operation matches %s %s", req.Method, req.URL.Path),
+	}}}
+}
+
+// matchSegments reports whether the path segments match the template segments of the same length.
+func matchSegments(template, segments []string) bool {
+	for i, seg := range template {
+		if seg != "" && seg != segments[i] || seg == "" && segments[i] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func validateSchemaNode(n *schemaNode, data []byte) error {
+	return (&JSONSchema{root: n}).Validate(data)
+}
+
+func isJSONContentType(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,201 @@
+package xhttpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const _testOpenAPI = `{
+	"openapi": "3.0.3",
+	"servers": [{"url": "https://api.example.com/v1"}, {"url": "/{version}/store"}],
+	"paths": {
+		"/pets/{id}": {
+			"get": {
+				"responses": {
+					"200": {"$ref": "#/components/responses/Pet"},
+					"4XX": {
+						"description": "error",
+						"content": {"application/problem+json": {"schema": {"type": "object", "required": ["title"]}}}
+					}
+				}
+			},
+			"put": {
+				"requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+				"responses": {"204": {"description": "updated"}}
+			}
+		},
+		"/pets/mine": {
+			"get": {"responses": {"default": {"description": "any", "content": {"text/plain": {}}}}}
+		}
+	},
+	"components": {
+		"responses": {
+			"Pet": {"description": "pet", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}
+		},
+		"schemas": {
+			"Pet": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {"name": {"type": "string"}, "tag": {"type": "string", "nullable": true}}
+			}
+		}
+	}
+}`
+
+func TestXClient_WithValidator_OpenAPI(t *testing.T) {
+	validator, err := NewOpenAPIValidator([]byte(_testOpenAPI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var received int
+	cli := NewClient().
+		BaseURL("https://api.example.com/v1").
+		WithValidator(validator).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received++
+			if r.Method == http.MethodPut {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			switch r.URL.Path {
+			case "/v1/pets/1", "/v2/store/pets/1", "/v1/pets/a/b":
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{"name":"a","tag":null}`)
+			case "/v1/pets/2":
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, `{"tag":1}`)
+			case "/v1/pets/3":
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"status":404}`)
+			case "/v1/pets/4":
+				w.WriteHeader(http.StatusInternalServerError)
+			case "/v1/pets/mine":
+				io.WriteString(w, "mine")
+			}
+		}))
+
+	var pet struct{ Name string }
+	if _, _, err = cli.Do(&pet, nil, NewGet().PathTemplate("pets/{id}").PathParam("id", "1")); err != nil || pet.Name != "a" {
+		t.Fatalf("pet = %+v, err = %v", pet, err)
+	}
+
+	// the escaped param is a segment
+	if _, _, err = cli.Do(&pet, nil, NewGet().PathTemplate("pets/{id}").PathParam("id", "a/b")); err != nil || pet.Name != "a" {
+		t.Fatalf("pet = %+v, err = %v", pet, err)
+	}
+
+	assertViolations := func(err error, want ...string) {
+		t.Helper()
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Fatalf("err = %v, want *ValidationError", err)
+		}
+		var got []string
+		for _, v := range ve.Violations {
+			got = append(got, v.Pointer+" "+v.Keyword)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("violations = %q, want %q", got, want)
+		}
+	}
+
+	_, respBody, err := cli.Do(&pet, nil, NewGet().Path("pets", "2"))
+	assertViolations(err, " required", "/tag type")
+	if !strings.Contains(err.Error(), "validate response") || string(respBody) != `{"tag":1}` {
+		t.Fatalf("err = %v, body = %s", err, respBody)
+	}
+
+	_, _, err = cli.Do(&pet, Discard, NewGet().Path("pets", "3"))
+	assertViolations(err, " required")
+
+	_, _, err = cli.Do(&pet, Discard, NewGet().Path("pets", "4"))
+	assertViolations(err, " responses")
+
+	if _, _, err = cli.Do(Discard, nil, NewGet().Path("pets", "mine")); err != nil {
+		t.Fatal(err)
+	}
+
+	before := received
+	_, _, err = cli.Do(Discard, nil, NewPut().Path("pets", "1").Body(map[string]any{"name": 1}))
+	assertViolations(err, "/name type")
+	if !strings.Contains(err.Error(), "validate request") || received != before {
+		t.Fatalf("err = %v, the invalid request is sent", err)
+	}
+	if _, _, err = cli.Do(Discard, nil, NewPut().Path("pets", "1").Body(map[string]any{"name": "b"})); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = cli.Do(Discard, nil, NewPost().Path("pets"))
+	assertViolations(err, " paths")
+
+	// anchored on the base paths of the servers
+	_, _, err = cli.Do(Discard, nil, NewGet().Path("orgs", "1", "pets", "2"))
+	assertViolations(err, " paths")
+	_, _, err = cli.Do(Discard, nil, NewGet().Path("https://api.example.com/pets/1"))
+	assertViolations(err, " paths")
+	if _, _, err = cli.Do(&pet, nil, NewGet().Path("https://api.example.com/v2/store/pets/1")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testStreamCodec encodes the body as a stream, which is not replayable.
+type testStreamCodec struct {
+	BodyCodec
+}
+
+func (c testStreamCodec) Get() BodyCodec { return testStreamCodec{c.BodyCodec.Get()} }
+
+func (c testStreamCodec) Put(bc BodyCodec) { c.BodyCodec.Put(bc.(testStreamCodec).BodyCodec) }
+
+func (c testStreamCodec) Encode(body any) (io.Reader, error) {
+	r, err := c.BodyCodec.Encode(body)
+	return io.MultiReader(r), err
+}
+
+func TestValidateRequest_notReplayable(t *testing.T) {
+	schema, err := CompileJSONSchema([]byte(`{"type": "object"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	}))
+	bc := testStreamCodec{BodyCodecJSON}
+
+	_, body, err := cli.DoOnceWithBodyCodec(bc, Discard, nil,
+		NewPost().Path("http://example.com").SetHeader("Content-Type", ContentTypeValueJSON).Body(map[string]int{"a": 1}).Validate(JSONSchemaValidator{Response: schema}))
+	if err != nil || string(body) != `{"a":1}`+"\n" {
+		t.Fatalf("body = %s, err = %v", body, err)
+	}
+
+	_, _, err = cli.DoOnceWithBodyCodec(bc, Discard, nil,
+		NewPost().Path("http://example.com").SetHeader("Content-Type", ContentTypeValueJSON).Body(map[string]int{"a": 1}).Validate(JSONSchemaValidator{Request: schema}))
+	if err == nil || !strings.Contains(err.Error(), "not replayable") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestXRequestBuilder_Validate(t *testing.T) {
+	schema, err := CompileJSONSchema([]byte(`{"type": "array", "items": {"type": "integer"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := NewClient().WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[1, 2.5]`)
+	}))
+
+	var v []float64
+	if _, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com")); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = cli.Do(&v, nil, NewGet().Path("http://example.com").Validate(JSONSchemaValidator{Response: schema}))
+	var ve *ValidationError
+	if !errors.As(err, &ve) || ve.Violations[0].Pointer != "/1" {
+		t.Fatalf("err = %v", err)
+	}
+}
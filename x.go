@@ -10,6 +10,7 @@ import (
 	"net/http"
 	urlpkg "net/url"
 	"path"
+	"sort"
 	"sync"
 )
 
@@ -78,3 +79,70 @@ func wrapCancelAndCloseRespBody(cancel context.CancelFunc, resp *http.Response)
 		resp.Body.Close()
 	}
 }
+
+func sortedHeaderKeys(header http.Header) []string {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// captureBody calls finish with the read bytes once the body is read to EOF or closed,
+// size counts every read byte while at most limit bytes are captured if limit is positive.
+type captureBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	n      int64
+	limit  int
+	once   sync.Once
+	finish func(body []byte, size int64)
+}
+
+func (b *captureBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.n += int64(n)
+	captured := p[:n]
+	if b.limit > 0 && len(captured) > b.limit-b.buf.Len() {
+		captured = captured[:b.limit-b.buf.Len()]
+	}
+	b.buf.Write(captured)
+	if err == io.EOF {
+		b.done()
+	}
+	return
+}
+
+func (b *captureBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.done()
+	return err
+}
+
+func (b *captureBody) done() {
+	b.once.Do(func() { b.finish(b.buf.Bytes(), b.n) })
+}
+
+// notifyBody calls finish once the body is read to EOF or closed, n counts the read bytes.
+type notifyBody struct {
+	io.ReadCloser
+	n      int64
+	once   sync.Once
+	finish func()
+}
+
+func (b *notifyBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err == io.EOF {
+		b.once.Do(b.finish)
+	}
+	return
+}
+
+func (b *notifyBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.finish)
+	return err
+}
@@ -0,0 +1,85 @@
+package xhttpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// diffJSON returns "" if got and want are equal JSON documents,
+// otherwise a line diff of their indented forms.
+func diffJSON(got, want []byte) string {
+	var gotV, wantV any
+	gotErr := json.Unmarshal(got, &gotV)
+	if err := json.Unmarshal(want, &wantV); err != nil {
+		return "invalid expected json: " + err.Error()
+	}
+	if gotErr == nil && reflect.DeepEqual(gotV, wantV) {
+		return ""
+	}
+
+	gotText := string(got)
+	if gotErr == nil {
+		gotText = indentJSON(gotV)
+	}
+	return diffLines(gotText, indentJSON(wantV))
+}
+
+func indentJSON(v any) string {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// diffLines returns a unified-like diff based on the longest common subsequence of lines.
+func diffLines(got, want string) string {
+	a, b := strings.Split(got, "\n"), strings.Split(want, "\n")
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || i < len(a) && lcs[i+1][j] >= lcs[i][j+1]:
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func sortedKeys[M ~map[string][]string](m M) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,64 @@
+package xhttpclienttest
+
+import (
+	"net/http"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+// NewRecorderFromHAR returns a Recorder in ModeReplay which replays the entries of h,
+// e.g. captured by xhttpclient.HARRecorder or exported from browser tooling.
+// Entries without a response (status 0) are skipped, the truncated bodies are replayed without Content-Length.
+func NewRecorderFromHAR(h *xhttpclient.HAR) *Recorder {
+	r := &Recorder{
+		mode:     ModeReplay,
+		matcher:  MatchAll(MatchMethod, MatchURL),
+		cassette: &Cassette{Version: cassetteVersion},
+	}
+
+	for _, e := range h.Log.Entries {
+		if e.Response.Status == 0 {
+			continue
+		}
+		body := e.Response.Content.Bytes()
+		header := harHeader(e.Response.Headers)
+		if int64(len(body)) < int64(e.Response.Content.Size) {
+			// truncated by xhttpclient.HARRecorder.WithMaxBodySize
+			header.Del("Content-Length")
+		}
+		i := &Interaction{
+			Request: RecordedRequest{
+				Method: e.Request.Method,
+				URL:    e.Request.URL,
+				Header: harHeader(e.Request.Headers),
+			},
+			Response: RecordedResponse{
+				StatusCode:   e.Response.Status,
+				Header:       header,
+				RecordedBody: newRecordedBody(body),
+			},
+		}
+		if e.Request.PostData != nil {
+			i.Request.RecordedBody = newRecordedBody(e.Request.PostData.Bytes())
+		}
+		r.cassette.Interactions = append(r.cassette.Interactions, i)
+	}
+	return r
+}
+
+// LoadHAR reads the HAR file and returns NewRecorderFromHAR.
+func LoadHAR(name string) (*Recorder, error) {
+	h, err := xhttpclient.ReadHARFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewRecorderFromHAR(h), nil
+}
+
+func harHeader(nvs []xhttpclient.HARNameValue) http.Header {
+	header := make(http.Header, len(nvs))
+	for _, nv := range nvs {
+		header.Add(nv.Name, nv.Value)
+	}
+	return header
+}
@@ -0,0 +1,95 @@
+package xhttpclienttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+func TestLoadHAR(t *testing.T) {
+	rec := xhttpclient.NewHARRecorder()
+	cli := xhttpclient.NewClient().
+		BaseURL("https://api.example.com").
+		WithHARRecorder(rec).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Path", r.URL.Path)
+			io.WriteString(w, `{"path":"`+r.URL.Path+`"}`)
+		}))
+
+	for _, p := range []string{"a", "b"} {
+		var successV map[string]string
+		if _, _, err := cli.Do(&successV, nil, xhttpclient.NewGet().Path(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := filepath.Join(t.TempDir(), "client.har")
+	if err := rec.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+
+	replayer, err := LoadHAR(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli = xhttpclient.NewClient().BaseURL("https://api.example.com").WithClient(replayer.Client())
+	for _, p := range []string{"b", "a"} {
+		var successV map[string]string
+		resp, _, err := cli.Do(&successV, nil, xhttpclient.NewGet().Path(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if successV["path"] != "/"+p || resp.Header.Get("X-Path") != "/"+p {
+			t.Fatalf("successV = %v, header = %v", successV, resp.Header)
+		}
+	}
+
+	var successV map[string]string
+	if _, _, err = cli.Do(&successV, nil, xhttpclient.NewGet().Path("a")); err == nil {
+		t.Fatal("replayed an entry twice")
+	}
+}
+
+func TestNewRecorderFromHAR_truncated(t *testing.T) {
+	rec := xhttpclient.NewHARRecorder().WithMaxBodySize(4)
+	cli := xhttpclient.NewClient().
+		WithHARRecorder(rec).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "10")
+			io.WriteString(w, "0123456789")
+		}))
+	_, resp, cancel, err := cli.DoWithRaw(xhttpclient.NewGet().Path("https://api.example.com/file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	cancel()
+
+	resp, err = NewRecorderFromHAR(rec.HAR()).Client().Get("https://api.example.com/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "0123" || resp.Header.Get("Content-Length") != "" || resp.ContentLength != 4 {
+		t.Fatalf("body = %q, header = %v, content length = %d", body, resp.Header, resp.ContentLength)
+	}
+}
+
+func TestNewRecorderFromHAR_binaryPostData(t *testing.T) {
+	binary := []byte{0xff, 0xfe, 0, 1}
+	h := &xhttpclient.HAR{Log: xhttpclient.HARLog{Entries: []*xhttpclient.HAREntry{{
+		Request: xhttpclient.HARRequest{
+			Method:   http.MethodPost,
+			URL:      "https://api.example.com/upload",
+			PostData: &xhttpclient.HARPostData{Text: base64.StdEncoding.EncodeToString(binary), Encoding: "base64"},
+		},
+		Response: xhttpclient.HARResponse{Status: http.StatusNoContent},
+	}}}}
+	if got := NewRecorderFromHAR(h).cassette.Interactions[0].Request.Bytes(); !bytes.Equal(got, binary) {
+		t.Fatalf("request body = %q, want %q", got, binary)
+	}
+}
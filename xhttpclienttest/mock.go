@@ -0,0 +1,339 @@
+// Package xhttpclienttest provides transports for testing code built on xhttpclient.
+package xhttpclienttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	urlpkg "net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TestingT is the subset of testing.TB used by this package.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Cleanup(func())
+}
+
+var _ http.RoundTripper = (*MockTransport)(nil)
+
+// MockTransport answers requests with the canned responses of registered expectations.
+//
+//	mock := xhttpclienttest.NewMockTransport(t)
+//	mock.Expect(http.MethodGet, "/users/{id}").
+//		WithQuery("fields", "name").
+//		RespondJSON(http.StatusOK, User{Name: "x"})
+//	cli := xhttpclient.NewClient().BaseURL("https://api.example.com").WithClient(mock.Client())
+type MockTransport struct {
+	t TestingT
+
+	mu           sync.Mutex
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewMockTransport returns a MockTransport which asserts on t.Cleanup
+// that all expectations were met and no unexpected request happened.
+func NewMockTransport(t TestingT) *MockTransport {
+	m := &MockTransport{t: t}
+	t.Cleanup(func() {
+		t.Helper()
+		m.AssertExpectations()
+	})
+	return m
+}
+
+func (m *MockTransport) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// Expect registers an expectation, method "" matches any method.
+//
+// pattern is matched against the escaped path segment by segment,
+// '{name}' matches any non-empty segment, others are matched with path.Match.
+func (m *MockTransport) Expect(method, pattern string) *Expectation {
+	e := &Expectation{
+		method:  method,
+		pattern: pattern,
+		times:   1,
+		status:  http.StatusOK,
+		header:  make(http.Header),
+	}
+	m.mu.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mu.Unlock()
+	return e
+}
+
+// AssertExpectations reports unmet expectations and unexpected requests, it returns true if there is none.
+func (m *MockTransport) AssertExpectations() bool {
+	m.t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := len(m.unexpected) == 0
+	for _, e := range m.expectations {
+		if e.times > 0 && e.calls < e.times {
+			ok = false
+			m.t.Errorf("xhttpclienttest: expected %s to be called %d time(s), got %d", e, e.times, e.calls)
+		}
+	}
+	if len(m.unexpected) != 0 {
+		m.t.Errorf("xhttpclienttest: %d unexpected request(s):\n%s", len(m.unexpected), strings.Join(m.unexpected, "\n"))
+	}
+	return ok
+}
+
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	var (
+		matched  *Expectation
+		closest  []string
+		closestN int
+	)
+	for _, e := range m.expectations {
+		if e.times > 0 && e.calls >= e.times {
+			continue
+		}
+		mismatches := e.mismatches(req, body)
+		if len(mismatches) == 0 {
+			matched = e
+			break
+		}
+		if closest == nil || len(mismatches) < closestN {
+			closestN = len(mismatches)
+			closest = append([]string{"closest expectation " + e.String() + ":"}, mismatches...)
+		}
+	}
+	if matched != nil {
+		matched.calls++
+	} else {
+		msg := fmt.Sprintf("%s %s", req.Method, req.URL)
+		if len(closest) != 0 {
+			msg += "\n\t" + strings.Join(closest, "\n\t")
+		}
+		m.unexpected = append(m.unexpected, msg)
+	}
+	m.mu.Unlock()
+
+	if matched == nil {
+		return nil, fmt.Errorf("xhttpclienttest: unexpected request %s %s", req.Method, req.URL)
+	}
+	if body != nil {
+		// the body is read for matching, RespondFunc reads it again
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return matched.respond(req)
+}
+
+type Expectation struct {
+	method    string
+	pattern   string
+	query     urlpkg.Values
+	reqHeader http.Header
+	jsonBody  []byte
+	times     int
+	calls     int
+
+	status    int
+	header    http.Header
+	body      []byte
+	err       error
+	respondFn func(req *http.Request) (*http.Response, error)
+}
+
+func (e *Expectation) String() string {
+	method := e.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + e.pattern
+}
+
+// WithQuery requires the query key to have exactly values.
+func (e *Expectation) WithQuery(key string, values ...string) *Expectation {
+	if e.query == nil {
+		e.query = make(urlpkg.Values)
+	}
+	e.query[key] = values
+	return e
+}
+
+// WithHeader requires the header key to have exactly values.
+func (e *Expectation) WithHeader(key string, values ...string) *Expectation {
+	if e.reqHeader == nil {
+		e.reqHeader = make(http.Header)
+	}
+	e.reqHeader[http.CanonicalHeaderKey(key)] = values
+	return e
+}
+
+// WithJSONBody requires the request body to be JSON equal to v (ignoring formatting and key order),
+// v can also be a string, []byte or json.RawMessage of JSON.
+func (e *Expectation) WithJSONBody(v any) *Expectation {
+	var err error
+	switch tv := v.(type) {
+	case string:
+		e.jsonBody = []byte(tv)
+	case []byte:
+		e.jsonBody = tv
+	case json.RawMessage:
+		e.jsonBody = tv
+	default:
+		if e.jsonBody, err = json.Marshal(v); err != nil {
+			panic(fmt.Sprintf("xhttpclienttest: marshal json body: %s", err))
+		}
+	}
+	return e
+}
+
+// Times sets how many times the expectation must be called, the default is 1.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// AnyTimes allows the expectation to be called any times, including zero.
+func (e *Expectation) AnyTimes() *Expectation {
+	e.times = 0
+	return e
+}
+
+func (e *Expectation) Respond(status int, body string) *Expectation {
+	e.status = status
+	e.body = []byte(body)
+	return e
+}
+
+func (e *Expectation) RespondJSON(status int, v any) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("xhttpclienttest: marshal json response: %s", err))
+	}
+	e.status = status
+	e.body = body
+	e.header.Set("Content-Type", "application/json; charset=utf-8")
+	return e
+}
+
+func (e *Expectation) RespondHeader(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// RespondError makes the transport fail with err, like a network error.
+func (e *Expectation) RespondError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+func (e *Expectation) RespondFunc(fn func(req *http.Request) (*http.Response, error)) *Expectation {
+	e.respondFn = fn
+	return e
+}
+
+func (e *Expectation) respond(req *http.Request) (*http.Response, error) {
+	switch {
+	case e.err != nil:
+		return nil, e.err
+	case e.respondFn != nil:
+		return e.respondFn(req)
+	}
+	return newResponse(req, e.status, e.header, e.body), nil
+}
+
+func (e *Expectation) mismatches(req *http.Request, body []byte) (ret []string) {
+	if e.method != "" && e.method != req.Method {
+		ret = append(ret, fmt.Sprintf("method: got %s, want %s", req.Method, e.method))
+	}
+	if !matchPath(e.pattern, req.URL.EscapedPath()) {
+		ret = append(ret, fmt.Sprintf("path: got %s, want %s", req.URL.EscapedPath(), e.pattern))
+	}
+
+	query := req.URL.Query()
+	for _, k := range sortedKeys(e.query) {
+		if got, want := query[k], e.query[k]; !equalStrings(got, want) {
+			ret = append(ret, fmt.Sprintf("query %q: got %q, want %q", k, got, want))
+		}
+	}
+	for _, k := range sortedKeys(e.reqHeader) {
+		if got, want := req.Header[k], e.reqHeader[k]; !equalStrings(got, want) {
+			ret = append(ret, fmt.Sprintf("header %q: got %q, want %q", k, got, want))
+		}
+	}
+
+	if e.jsonBody != nil {
+		if diff := diffJSON(body, e.jsonBody); diff != "" {
+			ret = append(ret, "json body (-got +want):\n"+diff)
+		}
+	}
+	return ret
+}
+
+func matchPath(pattern, p string) bool {
+	patterns := strings.Split(strings.Trim(pattern, "/"), "/")
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	if len(patterns) != len(segments) {
+		return false
+	}
+	for i, pat := range patterns {
+		if strings.HasPrefix(pat, "{") && strings.HasSuffix(pat, "}") {
+			if segments[i] == "" {
+				return false
+			}
+			continue
+		}
+		if ok, err := path.Match(pat, segments[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+func newResponse(req *http.Request, status int, header http.Header, body []byte) *http.Response {
+	if header = header.Clone(); header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
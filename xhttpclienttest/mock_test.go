@@ -0,0 +1,146 @@
+package xhttpclienttest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+type fakeT struct {
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Cleanup(fn func()) {
+	t.cleanups = append(t.cleanups, fn)
+}
+
+func (t *fakeT) cleanup() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestMockTransport(t *testing.T) {
+	type User struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+
+	mock := NewMockTransport(t)
+	mock.Expect(http.MethodGet, "/users/{id}").
+		WithQuery("fields", "id", "name").
+		WithHeader("X-Tenant", "t1").
+		RespondJSON(http.StatusOK, User{ID: "1", Name: "x"})
+	mock.Expect(http.MethodPost, "/users").
+		WithJSONBody(`{"name": "y", "id": "2"}`).
+		Times(2).
+		Respond(http.StatusCreated, `{"id":"2","name":"y"}`)
+	mock.Expect("", "/files/*.txt").
+		AnyTimes().
+		RespondHeader("X-Found", "1").
+		Respond(http.StatusOK, `{}`)
+
+	cli := xhttpclient.NewClient().
+		BaseURL("https://api.example.com").
+		SetHeader("X-Tenant", "t1").
+		WithClient(mock.Client())
+
+	var user User
+	if _, _, err := cli.Do(&user, nil, newGetUser("1")); err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "x" {
+		t.Fatalf("user = %+v", user)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, _, err := cli.Do(&user, nil, xhttpclient.NewPost().Path("users").Body(User{ID: "2", Name: "y"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusCreated || user.Name != "y" {
+			t.Fatalf("resp = %d, user = %+v", resp.StatusCode, user)
+		}
+	}
+
+	var empty any
+	resp, _, err := cli.Do(&empty, nil, xhttpclient.NewDelete().Path("files", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("X-Found") != "1" {
+		t.Fatalf("header = %v", resp.Header)
+	}
+}
+
+func TestExpectation_RespondFunc(t *testing.T) {
+	mock := NewMockTransport(t)
+	mock.Expect(http.MethodPost, "/echo").
+		WithJSONBody(`{"name":"x"}`).
+		RespondFunc(func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {xhttpclient.ContentTypeValueJSON}},
+				Body:       io.NopCloser(bytes.NewReader(body)),
+				Request:    req,
+			}, nil
+		})
+
+	var got map[string]string
+	if _, _, err := xhttpclient.NewClient().WithClient(mock.Client()).
+		Do(&got, nil, xhttpclient.NewPost().Path("https://api.example.com/echo").Body(map[string]string{"name": "x"})); err != nil {
+		t.Fatal(err)
+	}
+	if got["name"] != "x" {
+		t.Fatalf("echoed = %v", got)
+	}
+}
+
+func newGetUser(id string) *xhttpclient.XRequestBuilder {
+	return xhttpclient.NewGet().Path("users", id).AddQuery("fields", "id").AddQuery("fields", "name")
+}
+
+func TestMockTransport_Failures(t *testing.T) {
+	ft := new(fakeT)
+	mock := NewMockTransport(ft)
+	mock.Expect(http.MethodPost, "/users").WithJSONBody(map[string]any{"name": "y", "age": 1})
+	mock.Expect(http.MethodGet, "/never")
+
+	cli := xhttpclient.NewClient().BaseURL("https://api.example.com").WithClient(mock.Client())
+
+	var empty any
+	_, _, err := cli.Do(&empty, nil, xhttpclient.NewPost().Path("users").Body(map[string]any{"name": "z", "age": 1}))
+	if err == nil || !strings.Contains(err.Error(), "unexpected request POST https://api.example.com/users") {
+		t.Fatalf("err = %v", err)
+	}
+
+	ft.cleanup()
+	if len(ft.errors) != 3 {
+		t.Fatalf("errors = %q", ft.errors)
+	}
+	for _, want := range []string{
+		"expected POST /users to be called 1 time(s), got 0",
+		"expected GET /never to be called 1 time(s), got 0",
+		"closest expectation POST /users:\n\tjson body (-got +want):\n  {\n    \"age\": 1,\n-   \"name\": \"z\"\n+   \"name\": \"y\"\n  }",
+	} {
+		if !strings.Contains(strings.Join(ft.errors, "\n"), want) {
+			t.Errorf("errors = %s\nwant %s", strings.Join(ft.errors, "\n"), want)
+		}
+	}
+}
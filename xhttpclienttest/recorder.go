@@ -0,0 +1,360 @@
+package xhttpclienttest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	urlpkg "net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+type RecorderMode int
+
+const (
+	// ModeReplay only replays the cassette, a request without a matching interaction fails.
+	ModeReplay RecorderMode = iota
+	// ModeRecord always sends requests with the real transport and overwrites the cassette on Stop.
+	ModeRecord
+	// ModeReplayOrRecord replays matching interactions and records the others.
+	ModeReplayOrRecord
+)
+
+const Redacted = "REDACTED"
+
+const cassetteVersion = 1
+
+// Cassette is the file of recorded interactions, in YAML if its extension is .yaml or .yml, otherwise in JSON.
+type Cassette struct {
+	Version      int            `json:"version" yaml:"version"`
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request" yaml:"request"`
+	Response RecordedResponse `json:"response" yaml:"response"`
+
+	replayed bool
+}
+
+type RecordedRequest struct {
+	Method       string      `json:"method" yaml:"method"`
+	URL          string      `json:"url" yaml:"url"`
+	Header       http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	RecordedBody `yaml:",inline"`
+}
+
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code" yaml:"status_code"`
+	Header       http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	RecordedBody `yaml:",inline"`
+}
+
+// RecordedBody keeps text as is and base64 encodes binary bodies.
+type RecordedBody struct {
+	Body       string `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyBase64 bool   `json:"body_base64,omitempty" yaml:"body_base64,omitempty"`
+}
+
+func newRecordedBody(b []byte) RecordedBody {
+	if utf8.Valid(b) {
+		return RecordedBody{Body: string(b)}
+	}
+	return RecordedBody{Body: base64.StdEncoding.EncodeToString(b), BodyBase64: true}
+}
+
+func (rb RecordedBody) Bytes() []byte {
+	if rb.BodyBase64 {
+		b, _ := base64.StdEncoding.DecodeString(rb.Body)
+		return b
+	}
+	return []byte(rb.Body)
+}
+
+// Matcher reports whether the live request (already redacted) matches a recorded one.
+type Matcher func(live, recorded *RecordedRequest) bool
+
+func MatchMethod(live, recorded *RecordedRequest) bool {
+	return live.Method == recorded.Method
+}
+
+func MatchURL(live, recorded *RecordedRequest) bool {
+	return live.URL == recorded.URL
+}
+
+// MatchBody compares JSON bodies semantically and others byte by byte.
+func MatchBody(live, recorded *RecordedRequest) bool {
+	a, b := live.Bytes(), recorded.Bytes()
+	if json.Valid(a) && json.Valid(b) {
+		return diffJSON(a, b) == ""
+	}
+	return bytes.Equal(a, b)
+}
+
+func MatchAll(matchers ...Matcher) Matcher {
+	return func(live, recorded *RecordedRequest) bool {
+		for _, m := range matchers {
+			if !m(live, recorded) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+var _ http.RoundTripper = (*Recorder)(nil)
+
+// Recorder records the traffic of the real transport into a cassette and replays it deterministically,
+// every recorded interaction is replayed at most once, in order.
+//
+//	rec, err := xhttpclienttest.NewRecorder("testdata/github.json", xhttpclienttest.ModeReplayOrRecord)
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//	cli := xhttpclient.NewClient().WithClient(rec.Client())
+type Recorder struct {
+	path      string
+	mode      RecorderMode
+	transport http.RoundTripper
+	matcher   Matcher
+	redactHdr []string
+	redactQry []string
+	redactFn  func(i *Interaction)
+
+	mu       sync.Mutex
+	cassette *Cassette
+	changed  bool
+}
+
+// NewRecorder loads the cassette at path in JSON or YAML, see Cassette, it may not exist unless mode is ModeReplay.
+//
+// By default, requests are matched by method and URL,
+// and the 'Authorization', 'Proxy-Authorization', 'Cookie' and 'Set-Cookie' headers are redacted.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		matcher:   MatchAll(MatchMethod, MatchURL),
+		redactHdr: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		cassette:  &Cassette{Version: cassetteVersion},
+	}
+
+	if mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && mode == ModeReplayOrRecord:
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+	if isYAMLCassette(path) {
+		err = yaml.Unmarshal(data, r.cassette)
+	} else {
+		err = json.Unmarshal(data, r.cassette)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load cassette: %w", err)
+	}
+	return r, nil
+}
+
+// marshalCassette encodes the cassette in the format of its path.
+func (r *Recorder) marshalCassette() ([]byte, error) {
+	if !isYAMLCassette(r.path) {
+		data, err := json.MarshalIndent(r.cassette, "", "  ")
+		return append(data, '\n'), err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(r.cassette)
+	return buf.Bytes(), err
+}
+
+func isYAMLCassette(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// WithTransport sets the real transport used for recording, the default is http.DefaultTransport.
+func (r *Recorder) WithTransport(rt http.RoundTripper) *Recorder {
+	r.transport = rt
+	return r
+}
+
+func (r *Recorder) WithMatcher(m Matcher) *Recorder {
+	r.matcher = m
+	return r
+}
+
+// WithRedactHeaders replaces the values of request and response headers keys with Redacted.
+func (r *Recorder) WithRedactHeaders(keys ...string) *Recorder {
+	r.redactHdr = keys
+	return r
+}
+
+// WithRedactQuery replaces the values of query keys with Redacted, both in the cassette and before matching.
+func (r *Recorder) WithRedactQuery(keys ...string) *Recorder {
+	r.redactQry = keys
+	return r
+}
+
+// WithRedactFunc is called on every interaction before it is saved, e.g. to redact tokens in bodies.
+// It is also called on the live request, with an empty Response, before matching,
+// so that the live request matches its redacted recording. It must be idempotent.
+func (r *Recorder) WithRedactFunc(fn func(i *Interaction)) *Recorder {
+	r.redactFn = fn
+	return r
+}
+
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Stop saves the cassette if anything was recorded.
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.changed {
+		return nil
+	}
+	data, err := r.marshalCassette()
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	if err = os.WriteFile(r.path, data, 0o644); err != nil {
+		return err
+	}
+	r.changed = false
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	live := r.recordRequest(req, body)
+	if r.redactFn != nil {
+		i := &Interaction{Request: *live}
+		r.redactFn(i)
+		live = &i.Request
+	}
+
+	if r.mode != ModeRecord {
+		if i := r.replay(live); i != nil {
+			resp := newResponse(req, i.Response.StatusCode, i.Response.Header, i.Response.Bytes())
+			return resp, nil
+		}
+		if r.mode == ModeReplay {
+			return nil, fmt.Errorf("xhttpclienttest: no recorded interaction for %s %s", live.Method, live.URL)
+		}
+	}
+
+	outReq := req.Clone(req.Context())
+	if body != nil {
+		outReq.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.transport.RoundTrip(outReq)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	i := &Interaction{
+		Request: *live,
+		Response: RecordedResponse{
+			StatusCode:   resp.StatusCode,
+			Header:       r.redactHeader(resp.Header),
+			RecordedBody: newRecordedBody(respBody),
+		},
+		replayed: true,
+	}
+	if r.redactFn != nil {
+		r.redactFn(i)
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.changed = true
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+func (r *Recorder) replay(live *RecordedRequest) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, i := range r.cassette.Interactions {
+		if !i.replayed && r.matcher(live, &i.Request) {
+			i.replayed = true
+			return i
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) recordRequest(req *http.Request, body []byte) *RecordedRequest {
+	u := *req.URL
+	if len(r.redactQry) != 0 {
+		query := u.Query()
+		for _, k := range r.redactQry {
+			if _, ok := query[k]; ok {
+				query[k] = []string{Redacted}
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+	u.User = redactUserinfo(u.User)
+
+	return &RecordedRequest{
+		Method:       req.Method,
+		URL:          u.String(),
+		Header:       r.redactHeader(req.Header),
+		RecordedBody: newRecordedBody(body),
+	}
+}
+
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	header = header.Clone()
+	for _, k := range r.redactHdr {
+		if vv, ok := header[http.CanonicalHeaderKey(k)]; ok {
+			for i := range vv {
+				vv[i] = Redacted
+			}
+		}
+	}
+	return header
+}
+
+func redactUserinfo(u *urlpkg.Userinfo) *urlpkg.Userinfo {
+	if u == nil {
+		return nil
+	}
+	if _, ok := u.Password(); ok {
+		return urlpkg.UserPassword(u.Username(), Redacted)
+	}
+	return u
+}
@@ -0,0 +1,222 @@
+package xhttpclienttest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/electricbubble/xhttpclient"
+)
+
+func TestRecorder(t *testing.T) {
+	type Response struct {
+		N    int               `json:"n"`
+		Body map[string]string `json:"body"`
+	}
+
+	var n int32
+	server := xhttpclient.NewHandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Set-Cookie", "session=secret")
+		json.NewEncoder(w).Encode(Response{N: int(atomic.AddInt32(&n, 1)), Body: body})
+	}))
+
+	cassette := filepath.Join(t.TempDir(), "testdata", "cassette.json")
+	do := func(rec *Recorder, token string, body map[string]string) (Response, error) {
+		cli := xhttpclient.NewClient().
+			BaseURL("https://api.example.com").
+			SetBasicAuth("user", "pass").
+			WithClient(rec.Client())
+		var successV Response
+		_, _, err := cli.Do(&successV, nil,
+			xhttpclient.NewPost().
+				Path("items").
+				SetQuery("token", token).
+				Body(body),
+		)
+		return successV, err
+	}
+
+	// record
+	rec, err := NewRecorder(cassette, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.WithTransport(server).WithRedactQuery("token").WithMatcher(MatchAll(MatchMethod, MatchURL, MatchBody))
+	for i, v := range []string{"a", "b", "a"} {
+		got, err := do(rec, "t"+strconv.Itoa(i), map[string]string{"v": v})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.N != i+1 {
+			t.Fatalf("n = %d, want %d", got.N, i+1)
+		}
+	}
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{basicAuthValue("user", "pass"), "session=secret", "token=t0"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	// replay
+	rec, err = NewRecorder(cassette, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.WithTransport(testFailingTransport{t}).WithRedactQuery("token").WithMatcher(MatchAll(MatchMethod, MatchURL, MatchBody))
+	for _, tt := range []struct {
+		v     string
+		wantN int
+	}{
+		{v: "b", wantN: 2},
+		{v: "a", wantN: 1},
+		{v: "a", wantN: 3},
+	} {
+		got, err := do(rec, "other", map[string]string{"v": tt.v})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.N != tt.wantN || got.Body["v"] != tt.v {
+			t.Fatalf("got = %+v, want n = %d", got, tt.wantN)
+		}
+	}
+	if _, err = do(rec, "other", map[string]string{"v": "a"}); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("err = %v, want no recorded interaction", err)
+	}
+
+	// replay or record
+	rec, err = NewRecorder(cassette, ModeReplayOrRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.WithTransport(server).WithRedactQuery("token").WithMatcher(MatchAll(MatchMethod, MatchURL, MatchBody))
+	if got, err := do(rec, "x", map[string]string{"v": "c"}); err != nil || got.N != 4 {
+		t.Fatalf("got = %+v, err = %v", got, err)
+	}
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	var c Cassette
+	data, _ = os.ReadFile(cassette)
+	if err = json.Unmarshal(data, &c); err != nil || len(c.Interactions) != 4 {
+		t.Fatalf("interactions = %d, err = %v", len(c.Interactions), err)
+	}
+}
+
+type testFailingTransport struct {
+	t *testing.T
+}
+
+func (tr testFailingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr.t.Errorf("unexpected real request %s %s", req.Method, req.URL)
+	return nil, errors.New("unexpected real request")
+}
+
+func basicAuthValue(username, password string) string {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth(username, password)
+	return req.Header.Get("Authorization")
+}
+
+func TestRecorder_WithRedactFunc(t *testing.T) {
+	server := xhttpclient.NewHandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	redact := func(i *Interaction) {
+		var body map[string]string
+		if json.Unmarshal(i.Request.Bytes(), &body) == nil && body["password"] != "" {
+			body["password"] = Redacted
+			b, _ := json.Marshal(body)
+			i.Request.RecordedBody = RecordedBody{Body: string(b)}
+		}
+	}
+	cassette := filepath.Join(t.TempDir(), "cassette.json")
+	do := func(rec *Recorder, password string) error {
+		rec.WithRedactFunc(redact).WithMatcher(MatchAll(MatchMethod, MatchURL, MatchBody))
+		cli := xhttpclient.NewClient().WithClient(rec.Client())
+		_, _, err := cli.Do(xhttpclient.Discard, nil, xhttpclient.NewPost().
+			Path("https://api.example.com/login").
+			Body(map[string]string{"user": "u", "password": password}))
+		return err
+	}
+
+	rec, err := NewRecorder(cassette, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = do(rec.WithTransport(server), "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(cassette); strings.Contains(string(data), "secret") {
+		t.Fatalf("cassette contains the password:\n%s", data)
+	}
+
+	if rec, err = NewRecorder(cassette, ModeReplay); err != nil {
+		t.Fatal(err)
+	}
+	if err = do(rec.WithTransport(testFailingTransport{t}), "other"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewRecorder_yaml(t *testing.T) {
+	server := xhttpclient.NewHandlerTransport(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		io.WriteString(w, "line 1\nline 2\n")
+	}))
+	cassette := filepath.Join(t.TempDir(), "cassette.yaml")
+
+	rec, err := NewRecorder(cassette, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.WithTransport(server)
+	resp, err := rec.Client().Post("https://api.example.com/a", "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err = rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(cassette)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "status_code: 200") || !strings.Contains(string(data), "body: |\n") {
+		t.Fatalf("cassette:\n%s", data)
+	}
+
+	if rec, err = NewRecorder(cassette, ModeReplay); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = rec.Client().Post("https://api.example.com/a", "text/plain", strings.NewReader("body")); err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "line 1\nline 2\n" || resp.Header.Get("X-Path") != "/a" {
+		t.Fatalf("body = %q, header = %v", body, resp.Header)
+	}
+	if i := rec.cassette.Interactions[0]; i.Request.Body != "body" || i.Request.Method != http.MethodPost {
+		t.Fatalf("request = %+v", i.Request)
+	}
+}
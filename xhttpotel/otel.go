@@ -0,0 +1,172 @@
+// Package xhttpotel traces xhttpclient requests with OpenTelemetry.
+//
+//	cli := xhttpclient.NewClient().WithRequestTracer(xhttpotel.NewTracer(nil))
+package xhttpotel
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/electricbubble/xhttpclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/electricbubble/xhttpclient/xhttpotel"
+
+type Options struct {
+	// TracerProvider defaults to otel.GetTracerProvider().
+	TracerProvider trace.TracerProvider
+	// Propagator defaults to W3C trace context and baggage.
+	Propagator propagation.TextMapPropagator
+	// Attributes are added to every span.
+	Attributes []attribute.KeyValue
+}
+
+// Tracer starts a client span for every request, the span name is the method and the route if set by
+// XRequestBuilder.Route, e.g. "GET /users/{id}".
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	attrs      []attribute.KeyValue
+}
+
+var _ xhttpclient.RequestTracer = (*Tracer)(nil)
+
+func NewTracer(opts *Options) *Tracer {
+	if opts == nil {
+		opts = new(Options)
+	}
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	propagator := opts.Propagator
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+	}
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagator,
+		attrs:      opts.Attributes,
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, method, route string, header http.Header) (context.Context, xhttpclient.RequestSpan) {
+	name := method
+	attrs := make([]attribute.KeyValue, 0, len(t.attrs)+3)
+	attrs = append(attrs, t.attrs...)
+	if _knownMethods[method] {
+		attrs = append(attrs, semconv.HTTPRequestMethodKey.String(method))
+	} else {
+		name = "HTTP"
+		attrs = append(attrs, semconv.HTTPRequestMethodKey.String("_OTHER"), semconv.HTTPRequestMethodOriginal(method))
+	}
+	if route != "" {
+		name += " " + route
+		attrs = append(attrs, semconv.URLTemplate(route))
+	}
+
+	ctx, s := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	return ctx, &span{span: s}
+}
+
+var _knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+type span struct {
+	span trace.Span
+	once sync.Once
+}
+
+func (s *span) Request(req *http.Request) {
+	u := *req.URL
+	u.User = nil
+	attrs := []attribute.KeyValue{semconv.URLFull(u.String())}
+
+	host, port := u.Hostname(), u.Port()
+	if host != "" {
+		attrs = append(attrs, semconv.ServerAddress(host))
+	}
+	if port == "" {
+		switch u.Scheme {
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		}
+	}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, semconv.ServerPort(p))
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		attrs = append(attrs, semconv.NetworkPeerAddress(ip.String()))
+	}
+	s.span.SetAttributes(attrs...)
+}
+
+func (s *span) CodecError(op string, err error) {
+	s.span.AddEvent("xhttpclient.codec."+op+".error", trace.WithAttributes(
+		semconv.ExceptionType(fmt.Sprintf("%T", err)),
+		semconv.ExceptionMessage(err.Error()),
+	))
+}
+
+func (s *span) End(resp *http.Response, err error) {
+	s.once.Do(func() {
+		if resp != nil {
+			s.span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+			if resp.ProtoMajor != 0 {
+				s.span.SetAttributes(semconv.NetworkProtocolVersion(protocolVersion(resp)))
+			}
+			if n := resendCount(resp); n > 0 {
+				s.span.SetAttributes(semconv.HTTPRequestResendCount(n))
+			}
+			if resp.StatusCode >= 400 {
+				s.span.SetAttributes(semconv.ErrorTypeKey.String(strconv.Itoa(resp.StatusCode)))
+				s.span.SetStatus(codes.Error, "")
+			}
+		}
+		if err != nil {
+			if resp == nil || resp.StatusCode < 400 {
+				s.span.SetAttributes(semconv.ErrorTypeKey.String(fmt.Sprintf("%T", err)))
+			}
+			s.span.RecordError(err)
+			s.span.SetStatus(codes.Error, err.Error())
+		}
+		s.span.End()
+	})
+}
+
+func protocolVersion(resp *http.Response) string {
+	if resp.ProtoMinor == 0 && resp.ProtoMajor >= 2 {
+		return strconv.Itoa(resp.ProtoMajor)
+	}
+	return strconv.Itoa(resp.ProtoMajor) + "." + strconv.Itoa(resp.ProtoMinor)
+}
+
+// resendCount counts the redirects followed by http.Client.
+func resendCount(resp *http.Response) (n int) {
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		n++
+	}
+	return
+}
@@ -0,0 +1,142 @@
+package xhttpotel
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/electricbubble/xhttpclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracer() (*Tracer, *tracetest.InMemoryExporter, trace.Tracer) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return NewTracer(&Options{TracerProvider: tp}), exporter, tp.Tracer("test")
+}
+
+func TestTracer(t *testing.T) {
+	tracer, exporter, testTracer := newTestTracer()
+
+	var gotHeader http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/users/1", http.StatusFound)
+			return
+		}
+		gotHeader = r.Header.Clone()
+		io.WriteString(w, `{"id":1}`)
+	}))
+	defer ts.Close()
+
+	cli := xhttpclient.NewClient().
+		BaseURL(ts.URL).
+		SetHeader("X-Client", "1").
+		WithRequestTracer(tracer)
+
+	member, _ := baggage.NewMember("tenant", "t1")
+	bag, _ := baggage.New(member)
+	ctx, parent := testTracer.Start(baggage.ContextWithBaggage(context.Background(), bag), "parent")
+
+	var successV map[string]any
+	if _, _, err := cli.Do(&successV, nil, xhttpclient.NewGet().WithContext(ctx).Path("old").Route("/old")); err != nil {
+		t.Fatal(err)
+	}
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("spans = %d, want 2", len(spans))
+	}
+	s := spans[0]
+	if s.Name != "GET /old" || s.SpanKind != trace.SpanKindClient {
+		t.Fatalf("span = %s %s", s.Name, s.SpanKind)
+	}
+	if s.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("span is not a child of the context span")
+	}
+
+	if tp := gotHeader.Get("Traceparent"); !strings.Contains(tp, s.SpanContext.TraceID().String()) || !strings.Contains(tp, s.SpanContext.SpanID().String()) {
+		t.Fatalf("traceparent = %q", tp)
+	}
+	if gotHeader.Get("Baggage") != "tenant=t1" || gotHeader.Get("X-Client") != "1" {
+		t.Fatalf("header = %v", gotHeader)
+	}
+
+	attrs := attributeMap(s.Attributes)
+	for k, want := range map[attribute.Key]any{
+		"http.request.method":       "GET",
+		"url.template":              "/old",
+		"url.full":                  ts.URL + "/old",
+		"server.address":            "127.0.0.1",
+		"http.response.status_code": int64(200),
+		"http.request.resend_count": int64(1),
+		"network.protocol.version":  "1.1",
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s = %v, want %v", k, attrs[k], want)
+		}
+	}
+}
+
+func TestTracer_errors(t *testing.T) {
+	tracer, exporter, _ := newTestTracer()
+	cli := xhttpclient.NewClient().
+		BaseURL("https://api.example.com").
+		WithRequestTracer(tracer).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			io.WriteString(w, `not json`)
+		}))
+
+	var successV map[string]any
+	// encode
+	if _, _, err := cli.Do(&successV, nil, xhttpclient.NewPost().Path("a").Body(make(chan int))); err == nil {
+		t.Fatal("want encode error")
+	}
+	// decode
+	if _, _, err := cli.Do(&successV, nil, xhttpclient.NewGet().Path("b")); err == nil {
+		t.Fatal("want decode error")
+	}
+	// status
+	_, _, cancel, err := cli.DoWithRaw(xhttpclient.NewGet().Path("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(exporter.GetSpans()); n != 2 {
+		t.Fatalf("spans = %d before the body is closed", n)
+	}
+	cancel()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %d, want 3", len(spans))
+	}
+	for i, op := range []string{"encode", "decode"} {
+		s := spans[i]
+		if s.Status.Code != codes.Error || len(s.Events) == 0 || s.Events[0].Name != "xhttpclient.codec."+op+".error" {
+			t.Fatalf("spans[%d] = status %v, events %+v", i, s.Status, s.Events)
+		}
+	}
+	if attrs := attributeMap(spans[2].Attributes); spans[2].Status.Code != codes.Error || attrs["error.type"] != "404" {
+		t.Fatalf("spans[2] = status %v, attributes %v", spans[2].Status, attrs)
+	}
+}
+
+func attributeMap(kvs []attribute.KeyValue) map[attribute.Key]any {
+	m := make(map[attribute.Key]any, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value.AsInterface()
+	}
+	return m
+}
@@ -0,0 +1,137 @@
+// Package xhttpprom records xhttpclient metrics with Prometheus client_golang.
+//
+//	rec := xhttpprom.NewRecorder(nil)
+//	prometheus.MustRegister(rec)
+//	cli := xhttpclient.NewClient().WithMetricsRecorder(rec)
+package xhttpprom
+
+import (
+	"github.com/electricbubble/xhttpclient"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type Options struct {
+	// Namespace and Subsystem prefix the metric names, the default namespace is 'xhttpclient'.
+	Namespace string
+	Subsystem string
+	// ConstLabels are added to every metric, e.g. the client name.
+	ConstLabels prometheus.Labels
+
+	// DurationBuckets in seconds, the default is prometheus.DefBuckets.
+	DurationBuckets []float64
+	// SizeBuckets in bytes, the default is 100B to 10MB (powers of 10).
+	SizeBuckets []float64
+}
+
+var _defaultSizeBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+// Recorder is a prometheus.Collector of:
+//   - requests_total{method, host, route, status_class}
+//   - request_duration_seconds{method, host, route, status_class}
+//   - request_size_bytes{method, host, route, status_class}
+//   - response_size_bytes{method, host, route, status_class}
+//   - requests_in_flight{method, host, route}
+type Recorder struct {
+	requests     *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+}
+
+var (
+	_ xhttpclient.MetricsRecorder = (*Recorder)(nil)
+	_ prometheus.Collector        = (*Recorder)(nil)
+)
+
+func NewRecorder(opts *Options) *Recorder {
+	if opts == nil {
+		opts = new(Options)
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "xhttpclient"
+	}
+	durationBuckets := opts.DurationBuckets
+	if durationBuckets == nil {
+		durationBuckets = prometheus.DefBuckets
+	}
+	sizeBuckets := opts.SizeBuckets
+	if sizeBuckets == nil {
+		sizeBuckets = _defaultSizeBuckets
+	}
+
+	labels := []string{"method", "host", "route", "status_class"}
+	return &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        "requests_total",
+			Help:        "Total number of HTTP requests.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        "request_duration_seconds",
+			Help:        "HTTP request latencies in seconds, including reading the response body.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     durationBuckets,
+		}, labels),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        "request_size_bytes",
+			Help:        "HTTP request body sizes in bytes.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     sizeBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        "response_size_bytes",
+			Help:        "HTTP response body sizes in bytes.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     sizeBuckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   opts.Subsystem,
+			Name:        "requests_in_flight",
+			Help:        "Number of HTTP requests in flight.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"method", "host", "route"}),
+	}
+}
+
+func (r *Recorder) InFlight(labels xhttpclient.MetricsLabels, delta int) {
+	r.inFlight.WithLabelValues(labels.Method, labels.Host, labels.Route).Add(float64(delta))
+}
+
+func (r *Recorder) Observe(labels xhttpclient.MetricsLabels, m xhttpclient.RequestMetrics) {
+	values := []string{labels.Method, labels.Host, labels.Route, labels.StatusClass}
+	r.requests.WithLabelValues(values...).Inc()
+	r.duration.WithLabelValues(values...).Observe(m.Duration.Seconds())
+	if m.RequestSize >= 0 {
+		r.requestSize.WithLabelValues(values...).Observe(float64(m.RequestSize))
+	}
+	if m.Err == nil {
+		r.responseSize.WithLabelValues(values...).Observe(float64(m.ResponseSize))
+	}
+}
+
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.requests.Describe(ch)
+	r.duration.Describe(ch)
+	r.requestSize.Describe(ch)
+	r.responseSize.Describe(ch)
+	r.inFlight.Describe(ch)
+}
+
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.requests.Collect(ch)
+	r.duration.Collect(ch)
+	r.requestSize.Collect(ch)
+	r.responseSize.Collect(ch)
+	r.inFlight.Collect(ch)
+}
@@ -0,0 +1,62 @@
+package xhttpprom
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/electricbubble/xhttpclient"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := NewRecorder(&Options{SizeBuckets: []float64{10, 100}, DurationBuckets: []float64{60}})
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(rec)
+
+	cli := xhttpclient.NewClient().
+		BaseURL("https://api.example.com").
+		WithMetricsRecorder(rec).
+		WithHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/users/2" {
+				w.WriteHeader(http.StatusNotFound)
+			}
+			io.WriteString(w, `{"id":1}`)
+		}))
+
+	var successV map[string]any
+	for _, id := range []string{"1", "2"} {
+		cli.Do(&successV, nil, xhttpclient.NewGet().Path("users", id).Route("/users/{id}"))
+	}
+
+	expected := `
+# HELP xhttpclient_requests_total Total number of HTTP requests.
+# TYPE xhttpclient_requests_total counter
+xhttpclient_requests_total{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx"} 1
+xhttpclient_requests_total{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx"} 1
+# HELP xhttpclient_response_size_bytes HTTP response body sizes in bytes.
+# TYPE xhttpclient_response_size_bytes histogram
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx",le="10"} 1
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx",le="100"} 1
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx",le="+Inf"} 1
+xhttpclient_response_size_bytes_sum{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx"} 8
+xhttpclient_response_size_bytes_count{host="api.example.com",method="GET",route="/users/{id}",status_class="2xx"} 1
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx",le="10"} 1
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx",le="100"} 1
+xhttpclient_response_size_bytes_bucket{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx",le="+Inf"} 1
+xhttpclient_response_size_bytes_sum{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx"} 8
+xhttpclient_response_size_bytes_count{host="api.example.com",method="GET",route="/users/{id}",status_class="4xx"} 1
+# HELP xhttpclient_requests_in_flight Number of HTTP requests in flight.
+# TYPE xhttpclient_requests_in_flight gauge
+xhttpclient_requests_in_flight{host="api.example.com",method="GET",route="/users/{id}"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"xhttpclient_requests_total", "xhttpclient_response_size_bytes", "xhttpclient_requests_in_flight"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(rec, "xhttpclient_request_duration_seconds"); n != 2 {
+		t.Fatalf("request_duration_seconds series = %d, want 2", n)
+	}
+}